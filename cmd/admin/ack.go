@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"kindle_bot/render/textfmt"
+	"kindle_bot/utils"
+)
+
+func acknowledgeAlerts(cfg aws.Config, args []string) error {
+	fs := newFlagSet("ack")
+	all := fs.Bool("all", false, "Acknowledge every open critical alert")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: admin ack [-all] [alert-id ...]")
+		fs.PrintDefaults()
+		utils.PrintExitCodes(fs.Output())
+	}
+	fs.Parse(args)
+
+	if _, err := utils.FetchCheckerConfigs(cfg); err != nil {
+		return fmt.Errorf("failed to fetch checker configs: %w", err)
+	}
+
+	alerts, err := utils.FetchCriticalAlerts(cfg)
+	if err != nil {
+		return err
+	}
+
+	ids := fs.Args()
+	if *all {
+		ids = nil
+		for _, alert := range utils.SortedCriticalAlerts(alerts) {
+			ids = append(ids, alert.ID)
+		}
+	}
+
+	if len(ids) == 0 {
+		printOpenAlerts(alerts)
+		return nil
+	}
+
+	for _, id := range ids {
+		if err := utils.AcknowledgeCriticalAlert(cfg, id); err != nil {
+			return err
+		}
+		fmt.Printf("Acknowledged %s\n", id)
+	}
+	return nil
+}
+
+func printOpenAlerts(alerts map[string]utils.CriticalAlert) {
+	if len(alerts) == 0 {
+		fmt.Println("No open critical alerts")
+		return
+	}
+
+	for _, alert := range utils.SortedCriticalAlerts(alerts) {
+		fmt.Printf("%s\n  raised: %s by %s (alerted %d times)\n  %s\n",
+			alert.ID, textfmt.TimeJST(alert.RaisedAt), alert.Source, alert.AlertCount, alert.Message)
+	}
+}
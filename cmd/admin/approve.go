@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"os/user"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"kindle_bot/render/textfmt"
+	"kindle_bot/utils"
+)
+
+func approveChanges(cfg aws.Config, args []string) error {
+	return resolveApprovals(cfg, "approve", "Applied", args, utils.ApplyApproval)
+}
+
+func rejectChanges(cfg aws.Config, args []string) error {
+	return resolveApprovals(cfg, "reject", "Rejected", args, utils.RejectApproval)
+}
+
+func resolveApprovals(cfg aws.Config, name, done string, args []string, resolve func(cfg aws.Config, id, approver string) error) error {
+	fs := newFlagSet(name)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: admin %s [approval-id ...]\n", name)
+		fs.PrintDefaults()
+		utils.PrintExitCodes(fs.Output())
+	}
+	fs.Parse(args)
+
+	if _, err := utils.FetchCheckerConfigs(cfg); err != nil {
+		return fmt.Errorf("failed to fetch checker configs: %w", err)
+	}
+
+	if fs.NArg() == 0 {
+		approvals, err := utils.FetchPendingApprovals(cfg)
+		if err != nil {
+			return err
+		}
+		printPendingApprovals(approvals)
+		return nil
+	}
+
+	approver := "admin"
+	if u, err := user.Current(); err == nil {
+		approver = u.Username
+	}
+
+	for _, id := range fs.Args() {
+		if err := resolve(cfg, id, approver); err != nil {
+			return err
+		}
+		fmt.Printf("%s %s\n", done, id)
+	}
+	return nil
+}
+
+func printPendingApprovals(approvals map[string]utils.PendingApproval) {
+	if len(approvals) == 0 {
+		fmt.Println("No pending approvals")
+		return
+	}
+
+	for _, pending := range utils.SortedPendingApprovals(approvals) {
+		fmt.Printf("%s\n  created: %s by %s (+%d -%d lines)\n",
+			pending.ID, textfmt.TimeJST(pending.CreatedAt), pending.Checker, pending.Added, pending.Removed)
+	}
+}
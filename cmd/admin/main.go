@@ -0,0 +1,87 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"kindle_bot/utils"
+)
+
+type command struct {
+	description string
+	run         func(cfg aws.Config, args []string) error
+}
+
+var commands = map[string]command{
+	"ack":         {"List or acknowledge open critical alerts", acknowledgeAlerts},
+	"approve":     {"List or apply changes awaiting approval", approveChanges},
+	"migrate-key": {"Merge a dataset into a renamed object key and mark the old key deprecated", migrateKey},
+	"price-cap":   {"List books over the sale checker's max price cap", reportPriceCap},
+	"pull":        {"Download all datasets to a local directory", pullDatasets},
+	"push":        {"Upload edited datasets from a local directory (with diff preview)", pushDatasets},
+	"reject":      {"Discard changes awaiting approval", rejectChanges},
+	"vacation":    {"Turn vacation mode on or off", toggleVacation},
+}
+
+func main() {
+	flag.Usage = printUsage
+	flag.Parse()
+	utils.Run(process)
+}
+
+func process() error {
+	if flag.NArg() == 0 {
+		printUsage()
+		return nil
+	}
+
+	cmd, ok := commands[flag.Arg(0)]
+	if !ok {
+		printUsage()
+		return fmt.Errorf("%w: unknown command: %s", utils.ErrUsage, flag.Arg(0))
+	}
+
+	cfg, err := utils.InitAWSConfig()
+	if err != nil {
+		return err
+	}
+
+	return cmd.run(cfg, flag.Args()[1:])
+}
+
+func printUsage() {
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var lines []string
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("  %-12s %s", name, commands[name].description))
+	}
+
+	fmt.Fprintf(os.Stderr, `Usage: admin <command> [options]
+
+Commands:
+%s
+
+Run "admin <command> -h" for command options.
+`, strings.Join(lines, "\n"))
+	utils.PrintExitCodes(os.Stderr)
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: admin %s [options]\n", name)
+		fs.PrintDefaults()
+		utils.PrintExitCodes(fs.Output())
+	}
+	return fs
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"kindle_bot/utils"
+)
+
+func migrateKey(cfg aws.Config, args []string) error {
+	fs := newFlagSet("migrate-key")
+	from := fs.String("from", "", "Current (old) object key")
+	to := fs.String("to", "", "New object key")
+	dryRun := fs.Bool("dry-run", false, "Only show the merge preview")
+	yes := fs.Bool("yes", false, "Apply without confirmation")
+	fs.Parse(args)
+
+	if *from == "" || *to == "" || *from == *to {
+		fs.Usage()
+		return fmt.Errorf("%w: -from and -to must be different object keys", utils.ErrUsage)
+	}
+
+	oldBody, err := utils.GetObject(cfg, *from)
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s: %w", *from, err)
+	}
+
+	newBody, err := utils.GetObject(cfg, *to)
+	if err != nil && !errors.Is(err, utils.ErrObjectNotFound) {
+		return fmt.Errorf("failed to fetch %s: %w", *to, err)
+	}
+
+	// Fetched after both objects so reading the deprecated key here does not
+	// trigger the checkers' deprecation warning.
+	checkerConfigs, err := utils.FetchCheckerConfigs(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to fetch checker configs: %w", err)
+	}
+
+	// On the first pass the old key is still the one the checkers write to,
+	// so its values win. Once it is marked deprecated, the new key does.
+	_, migrated := checkerConfigs.DeprecatedObjectKeys[*from]
+	merged, err := mergeObjects(oldBody, newBody, !migrated)
+	if err != nil {
+		return fmt.Errorf("failed to merge %s into %s: %w", *from, *to, err)
+	}
+
+	if newBody == nil {
+		fmt.Printf("📄 %s does not exist yet, copying %s\n", *to, *from)
+	} else if bytes.Equal(newBody, merged) {
+		fmt.Printf("✅ %s already contains everything in %s\n", *to, *from)
+	} else {
+		fmt.Printf("📝 %s (merged from %s)\n", *to, *from)
+		printDiff(newBody, merged)
+	}
+
+	if *dryRun {
+		return nil
+	}
+	if !*yes && !confirm(fmt.Sprintf("Write %s and mark %s as deprecated?", *to, *from)) {
+		fmt.Println("Aborted")
+		return nil
+	}
+
+	if !bytes.Equal(newBody, merged) {
+		if err := utils.PutObject(cfg, string(merged), *to); err != nil {
+			return fmt.Errorf("failed to write %s: %w", *to, err)
+		}
+	}
+
+	deprecated := maps.Clone(checkerConfigs.DeprecatedObjectKeys)
+	if deprecated == nil {
+		deprecated = make(map[string]string)
+	}
+	deprecated[*from] = *to
+	if err := utils.PatchCheckerConfigs(cfg, "", map[string]any{"DeprecatedObjectKeys": deprecated}); err != nil {
+		return fmt.Errorf("%w: wrote %s, but failed to mark %s as deprecated: %w", utils.ErrPartialSuccess, *to, *from, err)
+	}
+
+	fmt.Printf("Migrated %s → %s. Update config.json / SSM to use %s, then run migrate-key again to pick up writes made to the old key in the meantime.\n", *from, *to, *to)
+	return nil
+}
+
+// mergeObjects merges the old object into the new one. Lists are merged by
+// entry ID and objects by key; when both sides hold a value for the same key
+// or are plain values such as progress indices, preferOld decides which wins.
+func mergeObjects(oldBody, newBody []byte, preferOld bool) ([]byte, error) {
+	if newBody == nil {
+		return oldBody, nil
+	}
+
+	var oldEntries, newEntries []any
+	if json.Unmarshal(oldBody, &oldEntries) == nil && json.Unmarshal(newBody, &newEntries) == nil {
+		positions := make(map[string]int)
+		for i, entry := range newEntries {
+			positions[entryID(entry)] = i
+		}
+		merged := slices.Clone(newEntries)
+		changed := false
+		for _, entry := range oldEntries {
+			i, exists := positions[entryID(entry)]
+			switch {
+			case !exists:
+				merged = append(merged, entry)
+				changed = true
+			case preferOld && !reflect.DeepEqual(merged[i], entry):
+				merged[i] = entry
+				changed = true
+			}
+		}
+		if !changed {
+			return newBody, nil
+		}
+		return marshalDataset(merged)
+	}
+
+	var oldObj, newObj map[string]any
+	if json.Unmarshal(oldBody, &oldObj) == nil && json.Unmarshal(newBody, &newObj) == nil {
+		changed := false
+		for key, v := range oldObj {
+			if current, exists := newObj[key]; !exists || (preferOld && !reflect.DeepEqual(current, v)) {
+				newObj[key] = v
+				changed = true
+			}
+		}
+		if !changed {
+			return newBody, nil
+		}
+		return marshalDataset(newObj)
+	}
+
+	if len(bytes.TrimSpace(newBody)) == 0 {
+		return oldBody, nil
+	}
+	if bytes.Equal(bytes.TrimSpace(oldBody), bytes.TrimSpace(newBody)) {
+		return newBody, nil
+	}
+	if preferOld {
+		fmt.Printf("⚠️  Replacing the value of the new key (%q) with the old one (%q)\n", bytes.TrimSpace(newBody), bytes.TrimSpace(oldBody))
+		return oldBody, nil
+	}
+	fmt.Printf("⚠️  Keeping the value of the new key (%q) over the old one (%q)\n", bytes.TrimSpace(newBody), bytes.TrimSpace(oldBody))
+	return newBody, nil
+}
+
+func marshalDataset(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "    ")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
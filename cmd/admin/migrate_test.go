@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestMergeObjects(t *testing.T) {
+	tests := []struct {
+		name      string
+		old, new  string
+		preferOld bool
+		expected  string
+	}{
+		{"new key missing", `42`, ``, true, `42`},
+		{"new key empty", `42`, ` `, true, `42`},
+		{"index before switching keys", `42`, `0`, true, `42`},
+		{"index after switching keys", `42`, `57`, false, `57`},
+		{"lists merged by ASIN", `[{"ASIN":"A","Title":"old"},{"ASIN":"B"}]`, `[{"ASIN":"A","Title":"new"},{"ASIN":"C"}]`, false,
+			`[{"ASIN":"A","Title":"new"},{"ASIN":"C"},{"ASIN":"B"}]`},
+		{"old list entries win before switching", `[{"ASIN":"A","Title":"old"},{"ASIN":"B"}]`, `[{"ASIN":"A","Title":"new"},{"ASIN":"C"}]`, true,
+			`[{"ASIN":"A","Title":"old"},{"ASIN":"C"},{"ASIN":"B"}]`},
+		{"objects merged by key", `{"a":1,"b":2}`, `{"b":3,"c":4}`, false, `{"a":1,"b":3,"c":4}`},
+		{"old object values win before switching", `{"a":1,"b":2}`, `{"b":3,"c":4}`, true, `{"a":1,"b":2,"c":4}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var newBody []byte
+			if tt.new != "" {
+				newBody = []byte(tt.new)
+			}
+
+			got, err := mergeObjects([]byte(tt.old), newBody, tt.preferOld)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var gotValue, expectedValue any
+			if err := json.Unmarshal(got, &gotValue); err != nil {
+				t.Fatalf("mergeObjects() = %s, not valid JSON: %v", got, err)
+			}
+			json.Unmarshal([]byte(tt.expected), &expectedValue)
+			if !reflect.DeepEqual(gotValue, expectedValue) {
+				t.Errorf("mergeObjects() = %s, expected %s", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"kindle_bot/utils"
+)
+
+func reportPriceCap(cfg aws.Config, args []string) error {
+	fs := newFlagSet("price-cap")
+	fs.Parse(args)
+
+	checkerConfigs, err := utils.FetchCheckerConfigs(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to fetch checker configs: %w", err)
+	}
+
+	saleConfig := checkerConfigs.SaleChecker
+	if saleConfig.MaxPriceCap <= 0 {
+		fmt.Println("SaleChecker.MaxPriceCap is not set; showing books flagged earlier")
+	}
+
+	var books []utils.KindleBook
+	for _, key := range []string{utils.EnvConfig.S3UnprocessedObjectKey, utils.EnvConfig.S3UpcomingObjectKey} {
+		fetched, err := utils.FetchASINs(cfg, key)
+		if err != nil {
+			return fmt.Errorf("failed to fetch %s: %w", key, err)
+		}
+		books = append(books, fetched...)
+	}
+
+	flagged := utils.FilterOverPriceCap(utils.UniqueASINs(books), saleConfig.MaxPriceCap)
+	if len(flagged) == 0 {
+		fmt.Println("No books over the max price cap")
+		return nil
+	}
+
+	action := "flagged"
+	if saleConfig.MaxPriceCapExclude {
+		action = "excluded"
+	}
+	fmt.Printf("%d book(s) over the max price cap (%d円, %s):\n", len(flagged), saleConfig.MaxPriceCap, action)
+	for _, book := range flagged {
+		status := "not checked yet"
+		if book.OverPriceCap {
+			status = action
+		}
+		fmt.Printf("%s  %6.0f円 (max %.0f円)  %s [%s]\n  %s\n",
+			book.ASIN, book.CurrentPrice, book.MaxPrice, book.Title, status, book.URL)
+	}
+	return nil
+}
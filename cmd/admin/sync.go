@@ -0,0 +1,325 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"kindle_bot/utils"
+)
+
+func pullDatasets(cfg aws.Config, args []string) error {
+	fs := newFlagSet("pull")
+	dir := fs.String("dir", "data", "Local directory to write datasets to")
+	fs.Parse(args)
+
+	if err := os.MkdirAll(*dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	for _, key := range utils.DatasetObjectKeys() {
+		body, err := utils.GetObject(cfg, key)
+		if errors.Is(err, utils.ErrObjectNotFound) {
+			fmt.Printf("⏭️  %s does not exist yet, skipped\n", key)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to fetch %s: %w", key, err)
+		}
+
+		path := filepath.Join(*dir, key)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", key, err)
+		}
+		if err := os.WriteFile(path, body, 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+
+		fmt.Printf("⬇️  %s (%d bytes)\n", path, len(body))
+	}
+
+	return nil
+}
+
+func pushDatasets(cfg aws.Config, args []string) error {
+	fs := newFlagSet("push")
+	dir := fs.String("dir", "data", "Local directory to read datasets from")
+	dryRun := fs.Bool("dry-run", false, "Only show the diff preview")
+	yes := fs.Bool("yes", false, "Apply without confirmation")
+	force := fs.Bool("force", false, "Bypass the shrink guard")
+	source := fs.String("source", utils.SourceManual, "Source recorded on newly added books (manual or import)")
+	fs.Parse(args)
+
+	if _, err := utils.FetchCheckerConfigs(cfg); err != nil {
+		return fmt.Errorf("failed to fetch checker configs: %w", err)
+	}
+
+	type pendingUpload struct {
+		key    string
+		remote []byte
+		body   []byte
+		shrunk error
+	}
+	var uploads []pendingUpload
+
+	for _, key := range utils.DatasetObjectKeys() {
+		path := filepath.Join(*dir, key)
+		local, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		// A dataset that does not exist yet is pushed as a new file.
+		remote, err := utils.GetObject(cfg, key)
+		if err != nil && !errors.Is(err, utils.ErrObjectNotFound) {
+			return fmt.Errorf("failed to fetch %s: %w", key, err)
+		}
+
+		if remote != nil && bytes.Equal(bytes.TrimSpace(local), bytes.TrimSpace(remote)) {
+			continue
+		}
+
+		if (remote == nil || json.Valid(remote)) && !json.Valid(local) {
+			return fmt.Errorf("%w: %s: local file is not valid JSON", utils.ErrData, path)
+		}
+
+		if slices.Contains(utils.BookDatasetObjectKeys(), key) {
+			local, err = annotateAddedBooks(remote, local, *source)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+		}
+
+		if remote == nil {
+			fmt.Printf("🆕 %s\n", key)
+		} else {
+			fmt.Printf("📝 %s\n", key)
+		}
+		printDiff(remote, local)
+
+		shrunk := utils.CheckShrink(key, remote, local)
+		if shrunk != nil {
+			if !*force {
+				return fmt.Errorf("%w (use -force to push anyway)", shrunk)
+			}
+			fmt.Printf("⚠️  %v (forced)\n", shrunk)
+		}
+
+		uploads = append(uploads, pendingUpload{key: key, remote: remote, body: local, shrunk: shrunk})
+	}
+
+	if len(uploads) == 0 {
+		fmt.Println("No changes to push")
+		return nil
+	}
+
+	if *dryRun {
+		fmt.Printf("Dry run: %d dataset(s) would be updated\n", len(uploads))
+		return nil
+	}
+
+	if !*yes && !confirm(fmt.Sprintf("Push %d dataset(s)?", len(uploads))) {
+		fmt.Println("Aborted")
+		return nil
+	}
+
+	for i, u := range uploads {
+		held, err := utils.HoldDatasetForApproval(cfg, u.key, u.remote, string(u.body))
+		if err == nil && !held {
+			err = utils.PutObject(cfg, string(u.body), u.key)
+		}
+		if err != nil {
+			if i > 0 {
+				return fmt.Errorf("%w: pushed %d of %d dataset(s), failed to push %s: %w", utils.ErrPartialSuccess, i, len(uploads), u.key, err)
+			}
+			return fmt.Errorf("failed to push %s: %w", u.key, err)
+		}
+		if held {
+			fmt.Printf("⏸️  %s is awaiting approval\n", u.key)
+			continue
+		}
+		fmt.Printf("⬆️  %s\n", u.key)
+
+		if u.shrunk != nil {
+			utils.RaiseCriticalAlert(cfg, "shrink-guard:"+u.key, fmt.Errorf("pushed with -force: %w", u.shrunk))
+		}
+
+		if u.key == utils.EnvConfig.S3AuthorsObjectKey {
+			if err := auditAuthors(cfg, u.body); err != nil {
+				return fmt.Errorf("%w: uploaded %s, but %w", utils.ErrPartialSuccess, u.key, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func auditAuthors(cfg aws.Config, body []byte) error {
+	var authors []struct {
+		Name string `json:"Name"`
+	}
+	if err := json.Unmarshal(body, &authors); err != nil {
+		return fmt.Errorf("failed to parse authors for the audit log: %w", err)
+	}
+
+	names := make([]string, len(authors))
+	for i, author := range authors {
+		names[i] = author.Name
+	}
+	if err := utils.AuditAuthors(cfg, names, time.Now()); err != nil {
+		return fmt.Errorf("failed to audit authors: %w", err)
+	}
+	return nil
+}
+
+func annotateAddedBooks(remote, local []byte, source string) ([]byte, error) {
+	var oldBooks, newBooks []utils.KindleBook
+	if remote != nil {
+		if err := json.Unmarshal(remote, &oldBooks); err != nil {
+			return nil, err
+		}
+	}
+	if err := json.Unmarshal(local, &newBooks); err != nil {
+		return nil, err
+	}
+
+	existing := make(map[string]struct{})
+	for _, b := range oldBooks {
+		existing[b.ASIN] = struct{}{}
+	}
+
+	now := time.Now()
+	annotated := false
+	for i, b := range newBooks {
+		if _, exists := existing[b.ASIN]; exists || b.Source != "" {
+			continue
+		}
+		newBooks[i] = utils.MarkSource(b, source, now)
+		annotated = true
+	}
+
+	if !annotated {
+		return local, nil
+	}
+
+	body, err := utils.FormatASINs(newBooks)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(body), nil
+}
+
+func confirm(prompt string) bool {
+	fmt.Printf("%s [y/N]: ", prompt)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+func printDiff(before, after []byte) {
+	var oldEntries, newEntries []any
+	if (before != nil && json.Unmarshal(before, &oldEntries) != nil) || json.Unmarshal(after, &newEntries) != nil {
+		fmt.Printf("  - %s\n  + %s\n", strings.TrimSpace(string(before)), strings.TrimSpace(string(after)))
+		return
+	}
+
+	oldMap := indexEntries(oldEntries)
+	newMap := indexEntries(newEntries)
+
+	var lines []string
+	for id, entry := range newMap {
+		old, exists := oldMap[id]
+		if !exists {
+			lines = append(lines, fmt.Sprintf("  + %s", describeEntry(entry)))
+		} else if !reflect.DeepEqual(old, entry) {
+			lines = append(lines, fmt.Sprintf("  ~ %s%s", describeEntry(entry), describeChangedFields(old, entry)))
+		}
+	}
+	for id, entry := range oldMap {
+		if _, exists := newMap[id]; !exists {
+			lines = append(lines, fmt.Sprintf("  - %s", describeEntry(entry)))
+		}
+	}
+	sort.Strings(lines)
+
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+	fmt.Printf("  entries: %d → %d\n", len(oldEntries), len(newEntries))
+}
+
+func indexEntries(entries []any) map[string]any {
+	m := make(map[string]any)
+	for _, entry := range entries {
+		m[entryID(entry)] = entry
+	}
+	return m
+}
+
+func entryID(entry any) string {
+	if obj, ok := entry.(map[string]any); ok {
+		for _, key := range []string{"ASIN", "Name"} {
+			if v, ok := obj[key].(string); ok && v != "" {
+				return v
+			}
+		}
+	}
+	b, _ := json.Marshal(entry)
+	return string(b)
+}
+
+func describeEntry(entry any) string {
+	obj, ok := entry.(map[string]any)
+	if !ok {
+		return fmt.Sprintf("%v", entry)
+	}
+	for _, key := range []string{"Title", "Name"} {
+		if v, ok := obj[key].(string); ok && v != "" {
+			if asin, ok := obj["ASIN"].(string); ok && asin != "" {
+				return fmt.Sprintf("%s (%s)", v, asin)
+			}
+			return v
+		}
+	}
+	return entryID(entry)
+}
+
+func describeChangedFields(before, after any) string {
+	oldObj, ok1 := before.(map[string]any)
+	newObj, ok2 := after.(map[string]any)
+	if !ok1 || !ok2 {
+		return ""
+	}
+
+	var fields []string
+	for key, v := range newObj {
+		if !reflect.DeepEqual(oldObj[key], v) {
+			fields = append(fields, fmt.Sprintf("%s: %v → %v", key, oldObj[key], v))
+		}
+	}
+	for key := range oldObj {
+		if _, exists := newObj[key]; !exists {
+			fields = append(fields, fmt.Sprintf("%s removed", key))
+		}
+	}
+	sort.Strings(fields)
+
+	return " [" + strings.Join(fields, ", ") + "]"
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"kindle_bot/utils"
+)
+
+// useGitDatasets points the dataset store at a local repository holding only
+// the checker configs, so unprocessed.json does not exist yet.
+func useGitDatasets(t *testing.T) {
+	t.Helper()
+
+	remote := t.TempDir()
+	if _, err := git.PlainInit(remote, true); err != nil {
+		t.Fatal(err)
+	}
+	upstream := t.TempDir()
+	repo, err := git.PlainInit(upstream, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{remote}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(upstream, "checker_configs.json"), []byte(`{}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := worktree.Add("checker_configs.json"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := worktree.Commit("seed", &git.CommitOptions{Author: &object.Signature{Name: "editor", When: time.Now()}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Push(&git.PushOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Mock mode keeps the pushed datasets in memory instead of committing them.
+	saved := utils.EnvConfig
+	utils.EnvConfig.PAAPIMode = utils.PAAPIModeMock
+	utils.EnvConfig.DatasetStore = utils.DatasetStoreGit
+	utils.EnvConfig.GitDatasetURL = remote
+	utils.EnvConfig.S3CheckerConfigObjectKey = "checker_configs.json"
+	utils.EnvConfig.S3UnprocessedObjectKey = "unprocessed.json"
+	t.Cleanup(func() {
+		utils.FlushDatasets()
+		utils.EnvConfig = saved
+	})
+}
+
+func TestSyncMissingDatasets(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, dir string)
+	}{
+		{
+			name: "pull skips a missing dataset",
+			run: func(t *testing.T, dir string) {
+				if err := pullDatasets(aws.Config{}, []string{"-dir", dir}); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := os.Stat(filepath.Join(dir, "checker_configs.json")); err != nil {
+					t.Errorf("checker_configs.json was not pulled: %v", err)
+				}
+				if _, err := os.Stat(filepath.Join(dir, "unprocessed.json")); !os.IsNotExist(err) {
+					t.Errorf("unprocessed.json was written for a missing dataset: %v", err)
+				}
+			},
+		},
+		{
+			name: "push creates a missing dataset",
+			run: func(t *testing.T, dir string) {
+				if err := os.WriteFile(filepath.Join(dir, "unprocessed.json"), []byte(`[{"ASIN":"B0TESTASIN","Title":"t"}]`), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				if err := pushDatasets(aws.Config{}, []string{"-dir", dir, "-yes"}); err != nil {
+					t.Fatal(err)
+				}
+				got, err := utils.GetObject(aws.Config{}, "unprocessed.json")
+				if err != nil || !strings.Contains(string(got), "B0TESTASIN") {
+					t.Errorf("unprocessed.json = %s, %v, expected the pushed dataset", got, err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useGitDatasets(t)
+			tt.run(t, t.TempDir())
+		})
+	}
+}
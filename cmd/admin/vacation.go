@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"kindle_bot/render/textfmt"
+	"kindle_bot/utils"
+)
+
+func toggleVacation(cfg aws.Config, args []string) error {
+	fs := newFlagSet("vacation")
+	until := fs.String("until", "", "Last day of the vacation in JST (YYYY-MM-DD); empty means until turned off")
+	off := fs.Bool("off", false, "Turn vacation mode off")
+	fs.Parse(args)
+
+	checkerConfigs, err := utils.FetchCheckerConfigs(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to fetch checker configs: %w", err)
+	}
+
+	if *off {
+		checkerConfigs.Vacation = utils.VacationConfig{}
+	} else {
+		checkerConfigs.Vacation = utils.VacationConfig{Enabled: true}
+		if *until != "" {
+			lastDay, err := time.ParseInLocation("2006-01-02", *until, time.FixedZone("JST", 9*60*60))
+			if err != nil {
+				return fmt.Errorf("%w: invalid -until date: %w", utils.ErrUsage, err)
+			}
+			checkerConfigs.Vacation.Until = lastDay.AddDate(0, 0, 1)
+		}
+	}
+
+	fields := map[string]any{"Enabled": checkerConfigs.Vacation.Enabled, "Until": checkerConfigs.Vacation.Until}
+	if err := utils.PatchCheckerConfigs(cfg, "Vacation", fields); err != nil {
+		return fmt.Errorf("failed to save checker configs: %w", err)
+	}
+
+	switch {
+	case *off:
+		fmt.Println("Vacation mode turned off; the digest will be delivered on the next vacation-digest run")
+	case checkerConfigs.Vacation.Until.IsZero():
+		fmt.Println("Vacation mode turned on until turned off")
+	default:
+		fmt.Printf("Vacation mode turned on until %s\n", textfmt.TimeJST(checkerConfigs.Vacation.Until))
+	}
+	return nil
+}
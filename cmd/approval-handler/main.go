@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"kindle_bot/render/textfmt"
+	"kindle_bot/utils"
+)
+
+const maxRequestAge = 5 * time.Minute
+
+type interactionPayload struct {
+	User struct {
+		Name string `json:"username"`
+	} `json:"user"`
+	Actions []struct {
+		ActionID string `json:"action_id"`
+		Value    string `json:"value"`
+	} `json:"actions"`
+}
+
+func main() {
+	utils.RunFunctionURL(handle)
+}
+
+func handle(ctx context.Context, req events.LambdaFunctionURLRequest) (events.LambdaFunctionURLResponse, error) {
+	body := req.Body
+	if req.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(body)
+		if err != nil {
+			return respond(http.StatusBadRequest), nil
+		}
+		body = string(decoded)
+	}
+
+	if err := verifySignature(req.Headers, body, time.Now()); err != nil {
+		log.Println("Rejected request:", err)
+		return respond(http.StatusUnauthorized), nil
+	}
+
+	form, err := url.ParseQuery(body)
+	if err != nil {
+		return respond(http.StatusBadRequest), nil
+	}
+
+	if form.Get("command") != "" {
+		return handleCommand(form.Get("text"), form.Get("user_name"))
+	}
+
+	var payload interactionPayload
+	if err := json.Unmarshal([]byte(form.Get("payload")), &payload); err != nil {
+		return respond(http.StatusBadRequest), nil
+	}
+
+	cfg, err := utils.InitAWSConfig()
+	if err != nil {
+		return respond(http.StatusInternalServerError), err
+	}
+
+	for _, action := range payload.Actions {
+		if err := processAction(cfg, action.ActionID, action.Value, payload.User.Name); err != nil {
+			log.Println("Error processing Slack action:", err)
+			if !errors.Is(err, utils.ErrApprovalNotFound) && !errors.Is(err, utils.ErrAlertNotFound) {
+				utils.AlertToSlack(err, false)
+			}
+		}
+	}
+
+	return respond(http.StatusOK), nil
+}
+
+func processAction(cfg aws.Config, actionID, id, user string) error {
+	if _, err := utils.FetchCheckerConfigs(cfg); err != nil {
+		return fmt.Errorf("failed to fetch checker configs: %w", err)
+	}
+
+	switch actionID {
+	case utils.ApproveActionID:
+		log.Printf("%s approved %s", user, id)
+		return utils.ApplyApproval(cfg, id, user)
+	case utils.RejectActionID:
+		log.Printf("%s rejected %s", user, id)
+		return utils.RejectApproval(cfg, id, user)
+	case utils.AcknowledgeAlertActionID:
+		log.Printf("%s acknowledged %s", user, id)
+		if err := utils.AcknowledgeCriticalAlert(cfg, id); err != nil {
+			return err
+		}
+		return utils.PostToSlack(fmt.Sprintf("✅ %s を確認済みにしました (%s)", id, user), utils.EnvConfig.SlackErrorChannel)
+	default:
+		return fmt.Errorf("unknown action: %s", actionID)
+	}
+}
+
+// handleCommand answers the snooze slash command, e.g. "/snooze 168h",
+// "/snooze 168h mute", "/snooze 168h 800 60" or "/snooze off".
+func handleCommand(text, user string) (events.LambdaFunctionURLResponse, error) {
+	req, off, err := parseSnoozeCommand(text)
+	if err != nil {
+		return respondText(fmt.Sprintf("%v\n使い方: /snooze <期間 (例: 168h)> [mute | <セール閾値> [ポイント還元率]] または /snooze off", err)), nil
+	}
+
+	cfg, err := utils.InitAWSConfig()
+	if err != nil {
+		return respond(http.StatusInternalServerError), err
+	}
+	checkerConfigs, err := utils.FetchCheckerConfigs(cfg)
+	if err != nil {
+		return respond(http.StatusInternalServerError), err
+	}
+
+	if off {
+		if err := utils.UnsnoozeSales(cfg); err != nil {
+			return respond(http.StatusInternalServerError), err
+		}
+		log.Printf("%s cleared the sale snooze", user)
+		return respondText("スヌーズを解除しました"), nil
+	}
+
+	now := time.Now()
+	saleConfig, err := utils.SnoozeSales(cfg, checkerConfigs.SaleChecker, req, now)
+	if errors.Is(err, utils.ErrUsage) {
+		return respondText(err.Error()), nil
+	}
+	if err != nil {
+		return respond(http.StatusInternalServerError), err
+	}
+
+	log.Printf("%s snoozed sales until %s", user, textfmt.TimeJST(saleConfig.SnoozeUntil))
+	if saleConfig.SnoozeMuteNotifications {
+		return respondText(fmt.Sprintf("%s までセール通知をミュートします", textfmt.TimeJST(saleConfig.SnoozeUntil))), nil
+	}
+	saleThreshold, pointPercent := saleConfig.Thresholds(now)
+	return respondText(fmt.Sprintf("%s までセール閾値を %d円 / %d%% に引き上げます", textfmt.TimeJST(saleConfig.SnoozeUntil), saleThreshold, pointPercent)), nil
+}
+
+func parseSnoozeCommand(text string) (utils.SnoozeRequest, bool, error) {
+	var req utils.SnoozeRequest
+	args := strings.Fields(text)
+	if len(args) == 0 {
+		return req, false, fmt.Errorf("期間を指定してください")
+	}
+	if len(args) == 1 && args[0] == "off" {
+		return req, true, nil
+	}
+
+	duration, err := time.ParseDuration(args[0])
+	if err != nil || duration <= 0 {
+		return req, false, fmt.Errorf("期間が不正です: %s", args[0])
+	}
+	req.Duration = duration
+
+	rest := args[1:]
+	if len(rest) == 1 && rest[0] == "mute" {
+		req.Mute = true
+		return req, false, nil
+	}
+	if len(rest) > 2 {
+		return req, false, fmt.Errorf("引数が多すぎます")
+	}
+
+	thresholds := []*int{&req.SaleThreshold, &req.PointPercent}
+	for i, arg := range rest {
+		n, err := strconv.Atoi(arg)
+		if err != nil || n <= 0 {
+			return req, false, fmt.Errorf("閾値が不正です: %s", arg)
+		}
+		*thresholds[i] = n
+	}
+	return req, false, nil
+}
+
+func verifySignature(headers map[string]string, body string, now time.Time) error {
+	if utils.EnvConfig.SlackSigningSecret == "" {
+		return fmt.Errorf("SLACK_SIGNING_SECRET is not configured")
+	}
+
+	timestamp := headers["x-slack-request-timestamp"]
+	signature := headers["x-slack-signature"]
+	if timestamp == "" || signature == "" {
+		return fmt.Errorf("missing Slack signature headers")
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp %q: %w", timestamp, err)
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > maxRequestAge || age < -maxRequestAge {
+		return fmt.Errorf("stale request timestamp: %s", timestamp)
+	}
+
+	mac := hmac.New(sha256.New, []byte(utils.EnvConfig.SlackSigningSecret))
+	mac.Write([]byte("v0:" + timestamp + ":" + body))
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+func respond(status int) events.LambdaFunctionURLResponse {
+	return events.LambdaFunctionURLResponse{StatusCode: status}
+}
+
+func respondText(text string) events.LambdaFunctionURLResponse {
+	body, _ := json.Marshal(map[string]string{"response_type": "ephemeral", "text": text})
+	return events.LambdaFunctionURLResponse{
+		StatusCode: http.StatusOK,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(body),
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"testing"
+	"time"
+
+	"kindle_bot/utils"
+)
+
+func TestVerifySignature(t *testing.T) {
+	utils.EnvConfig.SlackSigningSecret = "8f742231b10e8888abcd99yyyzzz85a5"
+	now := time.Unix(1700000000, 0)
+	body := "payload=%7B%7D"
+
+	sign := func(timestamp, body string) string {
+		mac := hmac.New(sha256.New, []byte(utils.EnvConfig.SlackSigningSecret))
+		mac.Write([]byte("v0:" + timestamp + ":" + body))
+		return "v0=" + hex.EncodeToString(mac.Sum(nil))
+	}
+	fresh := strconv.FormatInt(now.Unix(), 10)
+	stale := strconv.FormatInt(now.Add(-10*time.Minute).Unix(), 10)
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		wantErr bool
+	}{
+		{"Valid signature", map[string]string{"x-slack-request-timestamp": fresh, "x-slack-signature": sign(fresh, body)}, false},
+		{"Tampered body", map[string]string{"x-slack-request-timestamp": fresh, "x-slack-signature": sign(fresh, "payload=%7B%22x%22%7D")}, true},
+		{"Stale timestamp", map[string]string{"x-slack-request-timestamp": stale, "x-slack-signature": sign(stale, body)}, true},
+		{"Missing headers", map[string]string{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifySignature(tt.headers, body, now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("verifySignature() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseSnoozeCommand(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected utils.SnoozeRequest
+		off      bool
+		wantErr  bool
+	}{
+		{text: "168h", expected: utils.SnoozeRequest{Duration: 168 * time.Hour}},
+		{text: "24h mute", expected: utils.SnoozeRequest{Duration: 24 * time.Hour, Mute: true}},
+		{text: "24h 800 60", expected: utils.SnoozeRequest{Duration: 24 * time.Hour, SaleThreshold: 800, PointPercent: 60}},
+		{text: "24h 800", expected: utils.SnoozeRequest{Duration: 24 * time.Hour, SaleThreshold: 800}},
+		{text: " off ", off: true},
+		{text: "", wantErr: true},
+		{text: "a week", wantErr: true},
+		{text: "24h loud", wantErr: true},
+		{text: "24h 800 60 1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			req, off, err := parseSnoozeCommand(tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSnoozeCommand() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if req != tt.expected || off != tt.off {
+				t.Errorf("parseSnoozeCommand() = %+v, %v, expected %+v, %v", req, off, tt.expected, tt.off)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"kindle_bot/render"
+	"kindle_bot/render/textfmt"
+	"kindle_bot/utils"
+)
+
+const (
+	monthKeyFormat = "2006-01"
+	maxTootLength  = 500
+)
+
+func main() {
+	flag.Usage = utils.Usage
+	utils.Run(process)
+}
+
+func process() error {
+	cfg, err := utils.InitAWSConfig()
+	if err != nil {
+		return err
+	}
+
+	checkerConfigs, err := utils.FetchCheckerConfigs(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to fetch checker configs: %w", err)
+	}
+
+	changelogConfig := checkerConfigs.AuthorChangelog
+	if !changelogConfig.Enabled && utils.IsLambda() {
+		log.Printf("AuthorChangelog is disabled, skipping execution")
+		return nil
+	}
+
+	auditLog, err := utils.FetchAuthorsAuditLog(cfg)
+	if err != nil {
+		return err
+	}
+
+	from, to := previousMonth(time.Now())
+	month := from.Format(monthKeyFormat)
+	if auditLog.LastPublishedMonth >= month {
+		log.Printf("Changelog for %s has already been published", month)
+		return nil
+	}
+
+	utils.SetRunItem("%s", month)
+	added, removed := utils.NetAuthorChanges(auditLog.Entries, from, to)
+	log.Printf("Authors changelog for %s: %d added, %d removed", month, len(added), len(removed))
+
+	if len(added) > 0 || len(removed) > 0 {
+		if changelogConfig.Mastodon {
+			utils.Notify(cfg, utils.Notification{
+				Kind:   utils.NotificationAuthorChanges,
+				Public: true,
+				Render: func(textfmt.RenderContext) string {
+					return render.AuthorChangelogToot(from, added, removed, maxTootLength)
+				},
+			})
+		}
+
+		if changelogConfig.GistID != "" {
+			if err := utils.UpdateGist(changelogConfig.GistID, changelogConfig.GistFilename, render.AuthorChangelog(auditLog.Entries, to)); err != nil {
+				if changelogConfig.Mastodon {
+					return fmt.Errorf("%w: tooted the changelog but failed to update the authors changelog gist: %w", utils.ErrPartialSuccess, err)
+				}
+				return fmt.Errorf("failed to update authors changelog gist: %w", err)
+			}
+		}
+	}
+
+	return utils.MarkAuthorChangelogPublished(cfg, month)
+}
+
+func previousMonth(now time.Time) (time.Time, time.Time) {
+	to := utils.MonthStartJST(now)
+	return to.AddDate(0, -1, 0), to
+}
@@ -17,8 +17,10 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	paapi5 "github.com/goark/pa-api"
 	"github.com/goark/pa-api/entity"
-	"github.com/goark/pa-api/query"
 
+	"kindle_bot/querybuilder"
+	"kindle_bot/render"
+	"kindle_bot/render/textfmt"
 	"kindle_bot/utils"
 )
 
@@ -35,15 +37,10 @@ func init() {
 	flag.BoolVar(&organize, "o", false, "Organize and sort the author list (shorthand)")
 }
 
-type Author struct {
-	Name               string    `json:"Name"`
-	URL                string    `json:"URL"`
-	LatestReleaseDate  time.Time `json:"LatestReleaseDate"`
-	LatestReleaseTitle string    `json:"LatestReleaseTitle"`
-	LatestReleaseURL   string    `json:"LatestReleaseURL"`
-}
+type Author = utils.Author
 
 func main() {
+	flag.Usage = utils.Usage
 	flag.Parse()
 	utils.Run(process)
 }
@@ -80,10 +77,19 @@ func process() error {
 		return nil
 	}
 
-	if err = utils.PutS3Object(cfg, strconv.Itoa(index), utils.EnvConfig.S3PrevIndexNewReleaseObjectKey); err != nil {
+	if err = utils.AuditAuthors(cfg, authorNames(authors), time.Now()); err != nil {
+		return fmt.Errorf("failed to audit authors: %w", err)
+	}
+
+	if err = utils.PutObject(cfg, strconv.Itoa(index), utils.EnvConfig.S3PrevIndexNewReleaseObjectKey); err != nil {
 		return err
 	}
 
+	if err = utils.LoadWarmUp(cfg, time.Now()); err != nil {
+		return err
+	}
+	defer utils.SaveWarmUp(cfg)
+
 	if err = processCore(cfg, authors, index, checkerConfigs); err != nil {
 		return err
 	}
@@ -144,7 +150,7 @@ func organizeAuthorList(cfg aws.Config, checkerConfigs *utils.CheckerConfigs) er
 		return fmt.Errorf("failed to save authors to S3: %w", err)
 	}
 
-	if err := updateGist(authors, checkerConfigs); err != nil {
+	if err := updateGist(cfg, authors, checkerConfigs); err != nil {
 		return fmt.Errorf("failed to update gist: %w", err)
 	}
 
@@ -153,7 +159,7 @@ func organizeAuthorList(cfg aws.Config, checkerConfigs *utils.CheckerConfigs) er
 }
 
 func printNextTargetInfo(authors []Author, index int, nextExecutionTime time.Time, cycleDays float64) {
-	lineNumber := getAuthorLineNumber(index)
+	lineNumber := getAuthorLineNumber(authors, index)
 	currentItemCount := len(authors)
 	simulatedItemCount := currentItemCount + 1
 	simulatedIndex, _ := utils.GetIndexAndNextExecutionTime(simulatedItemCount, cycleDays)
@@ -171,7 +177,7 @@ Next execution: %s
 		currentPosition, currentItemCount, currentPercentage,
 		authorName,
 		lineNumber,
-		utils.FormatTimeJST(nextExecutionTime))
+		textfmt.TimeJST(nextExecutionTime))
 }
 
 func printSimulationResult(index, simulatedIndex, simulatedPosition, simulatedItemCount int, authors []Author, lineNumber int) {
@@ -193,19 +199,19 @@ Solution: Insert new author at index %d (line %d) to be processed next
 Don't insert at index %d - it will be skipped!
 `,
 			index, authors[index].Name, lineNumber,
-			simulatedIndex, authors[simulatedIndex].Name, getAuthorLineNumber(simulatedIndex),
-			simulatedIndex, getAuthorLineNumber(simulatedIndex),
+			simulatedIndex, authors[simulatedIndex].Name, getAuthorLineNumber(authors, simulatedIndex),
+			simulatedIndex, getAuthorLineNumber(authors, simulatedIndex),
 			index)
 	}
 }
 
-func getAuthorLineNumber(index int) int {
-	authorType := reflect.TypeOf(Author{})
-	fieldCount := authorType.NumField()
-
-	linesPerAuthor := fieldCount + 2
-
-	return linesPerAuthor*index + 2
+func getAuthorLineNumber(authors []Author, index int) int {
+	lineNumber := 2
+	for _, author := range authors[:min(index, len(authors))] {
+		b, _ := json.MarshalIndent(author, "    ", "    ")
+		lineNumber += strings.Count(string(b), "\n") + 1
+	}
+	return lineNumber
 }
 
 func getAuthorToProcess(cfg aws.Config, checkerConfigs *utils.CheckerConfigs) ([]Author, int, error) {
@@ -214,6 +220,10 @@ func getAuthorToProcess(cfg aws.Config, checkerConfigs *utils.CheckerConfigs) ([
 		return nil, 0, fmt.Errorf("failed to fetch authors: %w", err)
 	}
 
+	if authors, err = expireWatchedAuthors(cfg, authors, checkerConfigs); err != nil {
+		return nil, 0, err
+	}
+
 	index, shouldProcess, nextExecutionTime, err := utils.ProcessSlot(cfg, len(authors), checkerConfigs.NewReleaseChecker.CycleDays, utils.EnvConfig.S3PrevIndexNewReleaseObjectKey)
 	if err != nil {
 		return nil, 0, err
@@ -223,12 +233,48 @@ func getAuthorToProcess(cfg aws.Config, checkerConfigs *utils.CheckerConfigs) ([
 	}
 
 	format := utils.GetCountFormat(len(authors))
-	log.Printf(fmt.Sprintf("Processing slot (%s / %s): %%s, next execution: %s (%s)", format, format, utils.FormatTimeJST(nextExecutionTime), utils.FormatExecutionInterval(nextExecutionTime)), index+1, len(authors), authors[index].Name)
+	log.Printf(fmt.Sprintf("Processing slot (%s / %s): %%s, next execution: %s (%s)", format, format, textfmt.TimeJST(nextExecutionTime), utils.FormatExecutionInterval(nextExecutionTime)), index+1, len(authors), authors[index].Name)
+	utils.SetRunItem("%d/%d %s", index+1, len(authors), authors[index].Name)
 	return authors, index, nil
 }
 
+func expireWatchedAuthors(cfg aws.Config, authors []Author, checkerConfigs *utils.CheckerConfigs) ([]Author, error) {
+	now := time.Now()
+	remaining := make([]Author, 0, len(authors))
+	var expired []utils.ArchiveEntry
+	for _, author := range authors {
+		if !utils.WatchExpired(author.WatchUntil, now) {
+			remaining = append(remaining, author)
+			continue
+		}
+
+		entry, err := utils.NewArchiveEntry(utils.ArchiveKindAuthor, author.Name, author.URL, author.WatchUntil, author, now)
+		if err != nil {
+			return nil, err
+		}
+		expired = append(expired, entry)
+	}
+
+	if len(expired) == 0 {
+		return authors, nil
+	}
+
+	if err := utils.ArchiveExpiredWatches(cfg, expired); err != nil {
+		return nil, fmt.Errorf("failed to archive expired authors: %w", err)
+	}
+
+	if err := saveAuthors(cfg, remaining); err != nil {
+		return nil, err
+	}
+
+	if err := updateGist(cfg, remaining, checkerConfigs); err != nil {
+		return nil, err
+	}
+	return remaining, nil
+}
+
 func fetchAuthors(cfg aws.Config) ([]Author, error) {
-	body, err := utils.GetS3Object(cfg, utils.EnvConfig.S3AuthorsObjectKey)
+	body, err := utils.GetObject(cfg, utils.EnvConfig.S3AuthorsObjectKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch authors: %w", err)
 	}
@@ -239,9 +285,20 @@ func fetchAuthors(cfg aws.Config) ([]Author, error) {
 	return authors, nil
 }
 
+func authorNames(authors []Author) []string {
+	names := make([]string, len(authors))
+	for i, author := range authors {
+		names[i] = author.Name
+	}
+	return names
+}
+
 func processCore(cfg aws.Config, authors []Author, index int, checkerConfigs *utils.CheckerConfigs) error {
 	start := time.Now()
-	client := utils.CreateClient()
+	client, err := utils.CreateClient()
+	if err != nil {
+		return err
+	}
 	author := &authors[index]
 
 	if author.Name == "" {
@@ -275,20 +332,16 @@ func processCore(cfg aws.Config, authors []Author, index int, checkerConfigs *ut
 			continue
 		}
 
-		utils.LogAndNotify(fmt.Sprintf(strings.TrimSpace(`
-📚 新刊予定があります: %s
-作者: %s
-発売日: %s
-ASIN: %s
-%s`),
-			item.ItemInfo.Title.DisplayValue,
-			author.Name,
-			item.ItemInfo.ProductInfo.ReleaseDate.DisplayValue.Format("2006-01-02"),
-			item.ASIN,
-			item.DetailPageURL,
-		), true)
-
-		b := utils.MakeBook(item, 0)
+		utils.Notify(cfg, utils.Notification{
+			Kind:        utils.NotificationNewRelease,
+			ReleaseDate: item.ItemInfo.ProductInfo.ReleaseDate.DisplayValue.Time,
+			Public:      true,
+			Render: func(rc textfmt.RenderContext) string {
+				return render.NewRelease(rc, item, author.Name)
+			},
+		})
+
+		b := utils.MarkSource(utils.MakeBook(item, 0), utils.SourceNewRelease, start)
 		notifiedMap[item.ASIN] = b
 		upcomingMap[item.ASIN] = b
 	}
@@ -301,12 +354,19 @@ ASIN: %s
 		return err
 	}
 
+	if author.TrackAudible {
+		if err := processAudible(cfg, client, author, ngWords, checkerConfigs, start); err != nil {
+			utils.PutMetric(cfg, "KindleBot/NewReleaseChecker", "SlotFailure")
+			return formatProcessError(index, authors, err)
+		}
+	}
+
 	if !author.LatestReleaseDate.Equal(latest) {
 		authors = sortUniqueAuthors(authors)
 		if err := saveAuthors(cfg, authors); err != nil {
 			return err
 		}
-		if err := updateGist(authors, checkerConfigs); err != nil {
+		if err := updateGist(cfg, authors, checkerConfigs); err != nil {
 			return err
 		}
 	}
@@ -314,8 +374,74 @@ ASIN: %s
 	return nil
 }
 
+func processAudible(cfg aws.Config, client paapi5.Client, author *Author, ngWords []string, checkerConfigs *utils.CheckerConfigs, now time.Time) error {
+	if checkerConfigs.AudibleBrowseNodeID == "" {
+		log.Printf("AudibleBrowseNodeID is not set, skipping the Audible search for %s", author.Name)
+		return nil
+	}
+
+	audibleMap, err := utils.FetchAudibleNotifiedASINs(cfg)
+	if err != nil {
+		return err
+	}
+
+	q := querybuilder.New(querybuilder.Audible(checkerConfigs.AudibleBrowseNodeID), querybuilder.Author(author.Name)).Query(client)
+	res, err := utils.SearchItems(cfg, client, q, checkerConfigs.NewReleaseChecker.SearchItemsPaapiRetryCount, checkerConfigs.NewReleaseChecker.SearchItemsInitialRetrySeconds)
+	if err != nil {
+		return fmt.Errorf("failed to search Audible editions: %w", err)
+	}
+	if res.SearchResult == nil {
+		return nil
+	}
+
+	found := false
+	for _, item := range res.SearchResult.Items {
+		if shouldSkipAudible(item, author, audibleMap, ngWords) {
+			continue
+		}
+
+		n := utils.Notification{
+			Kind:   utils.NotificationAudible,
+			Public: true,
+			Render: func(rc textfmt.RenderContext) string {
+				return render.Audible(rc, item, author.Name)
+			},
+		}
+		if item.ItemInfo.ProductInfo.ReleaseDate != nil {
+			n.ReleaseDate = item.ItemInfo.ProductInfo.ReleaseDate.DisplayValue.Time
+		}
+		utils.Notify(cfg, n)
+
+		audibleMap[item.ASIN] = utils.MarkSource(utils.MakeBook(item, 0), utils.SourceNewRelease, now)
+		found = true
+	}
+
+	if !found {
+		return nil
+	}
+	return utils.SaveAudibleNotifiedASINs(cfg, audibleMap)
+}
+
+func shouldSkipAudible(i entity.Item, author *Author, audibleMap map[string]utils.KindleBook, ngWords []string) bool {
+	if _, exists := audibleMap[i.ASIN]; exists {
+		return true
+	}
+	if !utils.IsAudibleItem(i) {
+		return true
+	}
+	for _, s := range ngWords {
+		if strings.Contains(i.ItemInfo.Title.DisplayValue, s) {
+			return true
+		}
+	}
+	if !matchesAuthorKeywords(author, i.ItemInfo.Title.DisplayValue) {
+		return true
+	}
+	return !isNameMatched(author, i)
+}
+
 func fetchExcludedTitleKeywords(cfg aws.Config) ([]string, error) {
-	body, err := utils.GetS3Object(cfg, utils.EnvConfig.S3ExcludedTitleKeywordsObjectKey)
+	body, err := utils.GetObject(cfg, utils.EnvConfig.S3ExcludedTitleKeywordsObjectKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch excluded keywords: %w", err)
 	}
@@ -327,12 +453,7 @@ func fetchExcludedTitleKeywords(cfg aws.Config) ([]string, error) {
 }
 
 func searchAuthorBooks(cfg aws.Config, client paapi5.Client, authorName string, checkerConfigs *utils.CheckerConfigs) ([]entity.Item, error) {
-	q := utils.CreateSearchQuery(
-		client,
-		query.Author,
-		authorName,
-		0,
-	)
+	q := querybuilder.New(querybuilder.KindleComics(), querybuilder.Author(authorName)).Query(client)
 
 	res, err := utils.SearchItems(cfg, client, q, checkerConfigs.NewReleaseChecker.SearchItemsPaapiRetryCount, checkerConfigs.NewReleaseChecker.SearchItemsInitialRetrySeconds)
 	if err != nil {
@@ -348,7 +469,7 @@ func searchAuthorBooks(cfg aws.Config, client paapi5.Client, authorName string,
 
 func formatProcessError(index int, authors []Author, err error) error {
 	return fmt.Errorf(
-		"%04d / %04d: %s\n%s\n%v",
+		"%04d / %04d: %s\n%s\n%w",
 		index+1,
 		len(authors),
 		authors[index].Name,
@@ -364,7 +485,7 @@ func shouldSkip(i entity.Item, author *Author, notifiedMap map[string]utils.Kind
 	if i.ItemInfo.ProductInfo.ReleaseDate == nil {
 		return true
 	}
-	if i.ItemInfo.Classifications.Binding.DisplayValue != "Kindle版" {
+	if !utils.IsKindleItem(i) {
 		return true
 	}
 	for _, s := range ngWords {
@@ -372,6 +493,9 @@ func shouldSkip(i entity.Item, author *Author, notifiedMap map[string]utils.Kind
 			return true
 		}
 	}
+	if !matchesAuthorKeywords(author, i.ItemInfo.Title.DisplayValue) {
+		return true
+	}
 	if yearMonthRegex.MatchString(i.ItemInfo.Title.DisplayValue) {
 		return true
 	}
@@ -392,6 +516,23 @@ func shouldSkip(i entity.Item, author *Author, notifiedMap map[string]utils.Kind
 	return false
 }
 
+func matchesAuthorKeywords(author *Author, title string) bool {
+	for _, s := range author.ExcludeKeywords {
+		if strings.Contains(title, s) {
+			return false
+		}
+	}
+	if len(author.RequireKeywords) == 0 {
+		return true
+	}
+	for _, s := range author.RequireKeywords {
+		if strings.Contains(title, s) {
+			return true
+		}
+	}
+	return false
+}
+
 func isNameMatched(author *Author, i entity.Item) bool {
 	authorName := normalizeName(author.Name)
 	for _, c := range i.ItemInfo.ByLineInfo.Contributors {
@@ -462,24 +603,10 @@ func saveAuthors(cfg aws.Config, authors []Author) error {
 		return err
 	}
 
-	return utils.PutS3Object(cfg, strings.ReplaceAll(string(prettyJSON), `\u0026`, "&"), utils.EnvConfig.S3AuthorsObjectKey)
+	return utils.PutObject(cfg, strings.ReplaceAll(string(prettyJSON), `\u0026`, "&"), utils.EnvConfig.S3AuthorsObjectKey)
 }
 
-func updateGist(authors []Author, checkerConfigs *utils.CheckerConfigs) error {
-	var lines []string
-
-	lines = append(lines, "| 作者 | 最新作 |")
-	lines = append(lines, "|------|--------|")
-	for _, author := range authors {
-		lines = append(lines, fmt.Sprintf("| [%s](%s) | [[%s] %s](%s) |",
-			author.Name,
-			author.URL,
-			author.LatestReleaseDate.Format("2006-01-02"),
-			author.LatestReleaseTitle,
-			author.LatestReleaseURL))
-	}
-
-	markdown := fmt.Sprintf("## 合計 %d人(最新の単行本発売日降順)\n%s", len(authors), strings.Join(lines, "\n"))
-
-	return utils.UpdateGist(checkerConfigs.NewReleaseChecker.GistID, checkerConfigs.NewReleaseChecker.GistFilename, markdown)
+func updateGist(cfg aws.Config, authors []Author, checkerConfigs *utils.CheckerConfigs) error {
+	markdown := render.AuthorList(textfmt.RenderContext{Now: time.Now()}, authors)
+	return utils.PublishGist(cfg, checkerConfigs.NewReleaseChecker.GistID, checkerConfigs.NewReleaseChecker.GistFilename, markdown)
 }
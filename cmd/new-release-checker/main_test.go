@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestMatchesAuthorKeywords(t *testing.T) {
+	tests := []struct {
+		name     string
+		author   Author
+		title    string
+		expected bool
+	}{
+		{
+			name:     "No per-author keywords",
+			author:   Author{Name: "作者"},
+			title:    "転生したらスライムだった件 1 (GCノベルズ)",
+			expected: true,
+		},
+		{
+			name:     "Required keyword present",
+			author:   Author{Name: "作者", RequireKeywords: []string{"コミック"}},
+			title:    "転生したらスライムだった件(1) (シリウスコミックス)",
+			expected: true,
+		},
+		{
+			name:     "Required keyword missing",
+			author:   Author{Name: "作者", RequireKeywords: []string{"コミック"}},
+			title:    "転生したらスライムだった件 1 (GCノベルズ)",
+			expected: false,
+		},
+		{
+			name:     "Any of several required keywords",
+			author:   Author{Name: "作者", RequireKeywords: []string{"コミック", "マンガ"}},
+			title:    "転生したらスライムだった件 1 (マンガUP!)",
+			expected: true,
+		},
+		{
+			name:     "Excluded spin-off series",
+			author:   Author{Name: "作者", ExcludeKeywords: []string{"異聞"}},
+			title:    "転生したらスライムだった件 異聞 1 (シリウスコミックス)",
+			expected: false,
+		},
+		{
+			name:     "Exclusion wins over requirement",
+			author:   Author{Name: "作者", ExcludeKeywords: []string{"異聞"}, RequireKeywords: []string{"コミック"}},
+			title:    "転生したらスライムだった件 異聞 1 (シリウスコミックス)",
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := matchesAuthorKeywords(&tt.author, tt.title)
+			if result != tt.expected {
+				t.Errorf("matchesAuthorKeywords(%q) = %v, expected %v", tt.title, result, tt.expected)
+			}
+		})
+	}
+}
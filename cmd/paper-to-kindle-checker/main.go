@@ -14,8 +14,10 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	paapi5 "github.com/goark/pa-api"
 	"github.com/goark/pa-api/entity"
-	"github.com/goark/pa-api/query"
 
+	"kindle_bot/querybuilder"
+	"kindle_bot/render"
+	"kindle_bot/render/textfmt"
 	"kindle_bot/utils"
 )
 
@@ -30,6 +32,7 @@ func init() {
 }
 
 func main() {
+	flag.Usage = utils.Usage
 	flag.Parse()
 	utils.Run(process)
 }
@@ -62,10 +65,15 @@ func process() error {
 		return nil
 	}
 
-	if err = utils.PutS3Object(cfg, strconv.Itoa(index), utils.EnvConfig.S3PrevIndexPaperToKindleObjectKey); err != nil {
+	if err = utils.PutObject(cfg, strconv.Itoa(index), utils.EnvConfig.S3PrevIndexPaperToKindleObjectKey); err != nil {
 		return err
 	}
 
+	if err = utils.LoadWarmUp(cfg, time.Now()); err != nil {
+		return err
+	}
+	defer utils.SaveWarmUp(cfg)
+
 	if err = processCore(cfg, books, index, checkerConfigs); err != nil {
 		return err
 	}
@@ -102,7 +110,7 @@ func organizeBookList(cfg aws.Config, checkerConfigs *utils.CheckerConfigs) erro
 		return fmt.Errorf("failed to save books to S3: %w", err)
 	}
 
-	if err := utils.UpdateBookGist(checkerConfigs.PaperToKindleChecker.GistID, checkerConfigs.PaperToKindleChecker.GistFilename, books); err != nil {
+	if err := updateGist(cfg, books, checkerConfigs); err != nil {
 		return fmt.Errorf("failed to update gist: %w", err)
 	}
 
@@ -116,6 +124,10 @@ func getBookToProcess(cfg aws.Config, checkerConfigs *utils.CheckerConfigs) ([]u
 		return nil, 0, fmt.Errorf("failed to fetch paper books: %w", err)
 	}
 
+	if books, err = expireWatchedBooks(cfg, books, checkerConfigs); err != nil {
+		return nil, 0, err
+	}
+
 	index, shouldProcess, nextExecutionTime, err := utils.ProcessSlot(cfg, len(books), checkerConfigs.PaperToKindleChecker.CycleDays, utils.EnvConfig.S3PrevIndexPaperToKindleObjectKey)
 	if err != nil {
 		return nil, 0, err
@@ -125,12 +137,28 @@ func getBookToProcess(cfg aws.Config, checkerConfigs *utils.CheckerConfigs) ([]u
 	}
 
 	format := utils.GetCountFormat(len(books))
-	log.Printf(fmt.Sprintf("Processing slot (%s / %s): %%s, next execution: %s (%s)", format, format, utils.FormatTimeJST(nextExecutionTime), utils.FormatExecutionInterval(nextExecutionTime)), index+1, len(books), books[index].Title)
+	log.Printf(fmt.Sprintf("Processing slot (%s / %s): %%s, next execution: %s (%s)", format, format, textfmt.TimeJST(nextExecutionTime), utils.FormatExecutionInterval(nextExecutionTime)), index+1, len(books), books[index].Title)
+	utils.SetRunItem("%d/%d %s", index+1, len(books), books[index].Title)
 	return books, index, nil
 }
 
+func expireWatchedBooks(cfg aws.Config, books []utils.KindleBook, checkerConfigs *utils.CheckerConfigs) ([]utils.KindleBook, error) {
+	remaining, err := utils.ExpireWatchedBooks(cfg, books, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to archive expired paper books: %w", err)
+	}
+	if len(remaining) == len(books) {
+		return books, nil
+	}
+
+	if err := savePaperBooksAndUpdateGist(cfg, remaining, checkerConfigs); err != nil {
+		return nil, err
+	}
+	return remaining, nil
+}
+
 func fetchPaperBooks(cfg aws.Config) ([]utils.KindleBook, error) {
-	body, err := utils.GetS3Object(cfg, utils.EnvConfig.S3PaperBooksObjectKey)
+	body, err := utils.GetObject(cfg, utils.EnvConfig.S3PaperBooksObjectKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch paper books: %w", err)
 	}
@@ -142,11 +170,14 @@ func fetchPaperBooks(cfg aws.Config) ([]utils.KindleBook, error) {
 }
 
 func processCore(cfg aws.Config, books []utils.KindleBook, index int, checkerConfigs *utils.CheckerConfigs) error {
-	client := utils.CreateClient()
+	client, err := utils.CreateClient()
+	if err != nil {
+		return err
+	}
 	book := &books[index]
 
 	if book.ASIN == "" {
-		return fmt.Errorf("empty ASIN found in paper book at index %d: Title=%s, URL=%s", index, book.Title, book.URL)
+		return fmt.Errorf("%w: empty ASIN found in paper book at index %d: Title=%s, URL=%s", utils.ErrData, index, book.Title, book.URL)
 	}
 
 	if book.CurrentPrice == 0 {
@@ -174,7 +205,7 @@ URL: %s`),
 			)
 		}
 
-		*book = utils.MakeBook(item, 0)
+		*book = utils.MarkSource(utils.CarryOverTracking(*book, utils.MakeBook(item, 0)), utils.SourceManual, time.Now())
 		if err := savePaperBooksAndUpdateGist(cfg, books, checkerConfigs); err != nil {
 			return err
 		}
@@ -188,7 +219,14 @@ URL: %s`),
 	utils.PutMetric(cfg, "KindleBot/PaperToKindleChecker", "APISuccess")
 
 	if kindleItem != nil {
-		utils.LogAndNotify(formatSlackMessage(*book, *kindleItem), true)
+		utils.Notify(cfg, utils.Notification{
+			Kind:        utils.NotificationPaperToKindle,
+			ReleaseDate: kindleItem.ItemInfo.ProductInfo.ReleaseDate.DisplayValue.Time,
+			Public:      true,
+			Render: func(rc textfmt.RenderContext) string {
+				return render.PaperToKindle(rc, *book, *kindleItem)
+			},
+		})
 
 		notifiedMap, err := utils.FetchNotifiedASINs(cfg, time.Now())
 		if err != nil {
@@ -196,7 +234,8 @@ URL: %s`),
 		}
 
 		upcomingMap := make(map[string]utils.KindleBook)
-		b := utils.MakeBook(*kindleItem, book.MaxPrice)
+		b := utils.MarkSource(utils.MakeBook(*kindleItem, book.MaxPrice), utils.SourcePaperToKindle, time.Now())
+		b.WatchUntil = book.WatchUntil
 		notifiedMap[kindleItem.ASIN] = b
 		upcomingMap[kindleItem.ASIN] = b
 
@@ -223,11 +262,16 @@ URL: %s`),
 	return nil
 }
 
+func updateGist(cfg aws.Config, books []utils.KindleBook, checkerConfigs *utils.CheckerConfigs) error {
+	markdown := render.BookList(textfmt.RenderContext{Now: time.Now()}, books)
+	return utils.PublishGist(cfg, checkerConfigs.PaperToKindleChecker.GistID, checkerConfigs.PaperToKindleChecker.GistFilename, markdown)
+}
+
 func formatProcessError(operation string, index int, books []utils.KindleBook, err error) error {
 	return fmt.Errorf(strings.TrimSpace(`
 %s: %03d / %03d
 https://www.amazon.co.jp/dp/%s
-%v`),
+%w`),
 		operation,
 		index+1,
 		len(books),
@@ -248,33 +292,15 @@ func savePaperBooksAndUpdateGist(cfg aws.Config, books []utils.KindleBook, check
 		return err
 	}
 
-	if err := utils.UpdateBookGist(checkerConfigs.PaperToKindleChecker.GistID, checkerConfigs.PaperToKindleChecker.GistFilename, books); err != nil {
+	if err := updateGist(cfg, books, checkerConfigs); err != nil {
 		return fmt.Errorf("failed to update gist: %w", err)
 	}
 
 	return nil
 }
 
-func formatSlackMessage(paper utils.KindleBook, kindle entity.Item) string {
-	return fmt.Sprintf(strings.TrimSpace(`
-📚 新刊予定があります: %s
-📕 紙書籍(%.0f円): %s
-📱 電子書籍(%.0f円): %s`),
-		kindle.ItemInfo.Title.DisplayValue,
-		paper.CurrentPrice,
-		paper.URL,
-		(*kindle.Offers.Listings)[0].Price.Amount,
-		kindle.DetailPageURL,
-	)
-}
-
 func searchKindleEdition(cfg aws.Config, client paapi5.Client, paper utils.KindleBook, checkerConfigs *utils.CheckerConfigs) (*entity.Item, error) {
-	q := utils.CreateSearchQuery(
-		client,
-		query.Title,
-		cleanTitle(paper.Title),
-		paper.CurrentPrice+20000,
-	)
+	q := querybuilder.New(querybuilder.KindleComics(), querybuilder.Title(cleanTitle(paper.Title))).Query(client)
 
 	res, err := utils.SearchItems(cfg, client, q, checkerConfigs.PaperToKindleChecker.SearchItemsPaapiRetryCount, checkerConfigs.PaperToKindleChecker.SearchItemsInitialRetrySeconds)
 	if err != nil {
@@ -308,7 +334,7 @@ func isSameKindleBook(paper utils.KindleBook, kindle entity.Item) bool {
 	if paper.ASIN == kindle.ASIN {
 		return false
 	}
-	if kindle.ItemInfo.Classifications.Binding.DisplayValue != "Kindle版" {
+	if !utils.IsKindleItem(kindle) {
 		return false
 	}
 	if kindle.ItemInfo.ProductInfo.ReleaseDate == nil {
@@ -1,16 +1,20 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 
+	"kindle_bot/render"
+	"kindle_bot/render/textfmt"
 	"kindle_bot/utils"
 )
 
 func main() {
+	flag.Usage = utils.Usage
 	utils.Run(process)
 }
 
@@ -20,15 +24,20 @@ func process() error {
 		return err
 	}
 
+	if _, err := utils.FetchCheckerConfigs(cfg); err != nil {
+		return fmt.Errorf("failed to fetch checker configs: %w", err)
+	}
+
 	today := time.Now().In(time.FixedZone("JST", 9*60*60))
 	log.Printf("Checking for books released on %s", today.Format("2006-01-02"))
+	utils.SetRunItem("%s発売", today.Format("2006-01-02"))
 
 	allBooks, err := getAllBooks(cfg)
 	if err != nil {
 		return err
 	}
 
-	processAndNotifyTodayBooks(allBooks, today)
+	processAndNotifyTodayBooks(cfg, allBooks, today)
 
 	return nil
 }
@@ -47,7 +56,7 @@ func getAllBooks(cfg aws.Config) ([]utils.KindleBook, error) {
 	return append(notifiedBooks, unprocessedBooks...), nil
 }
 
-func processAndNotifyTodayBooks(books []utils.KindleBook, today time.Time) {
+func processAndNotifyTodayBooks(cfg aws.Config, books []utils.KindleBook, today time.Time) {
 	seen := make(map[string]struct{})
 
 	for _, book := range books {
@@ -63,7 +72,14 @@ func processAndNotifyTodayBooks(books []utils.KindleBook, today time.Time) {
 		seen[book.ASIN] = struct{}{}
 
 		log.Printf("Notifying book [%s]: %s - %s", bookDate.Format("2006-01-02"), book.Title, book.URL)
-		utils.LogAndNotify(formatSingleBookMessage(book), true)
+		utils.Notify(cfg, utils.Notification{
+			Kind:        utils.NotificationReleaseToday,
+			ReleaseDate: book.ReleaseDate.Time,
+			Public:      true,
+			Render: func(rc textfmt.RenderContext) string {
+				return render.ReleaseToday(rc, book)
+			},
+		})
 	}
 }
 
@@ -72,7 +88,3 @@ func isSameDate(date1, date2 time.Time) bool {
 	y2, m2, d2 := date2.Date()
 	return y1 == y2 && m1 == m2 && d1 == d2
 }
-
-func formatSingleBookMessage(book utils.KindleBook) string {
-	return fmt.Sprintf("📚 本日発売の書籍\n%s\n%s", book.Title, book.URL)
-}
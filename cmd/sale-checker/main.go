@@ -4,26 +4,42 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"math"
 	"reflect"
 	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	paapi5 "github.com/goark/pa-api"
 	"github.com/goark/pa-api/entity"
 
+	"kindle_bot/querybuilder"
+	"kindle_bot/render"
+	"kindle_bot/render/textfmt"
 	"kindle_bot/utils"
 )
 
 var (
-	organize bool
+	organize            bool
+	snoozeDuration      time.Duration
+	snoozeMute          bool
+	snoozeSaleThreshold int
+	snoozePointPercent  int
+	unsnooze            bool
 )
 
 func init() {
 	flag.BoolVar(&organize, "organize", false, "Organize and sort the book list")
 	flag.BoolVar(&organize, "o", false, "Organize and sort the book list (shorthand)")
+	flag.DurationVar(&snoozeDuration, "snooze", 0, "Raise sale thresholds for the given duration (e.g. 168h)")
+	flag.BoolVar(&snoozeMute, "snooze-mute", false, "Mute sale notifications entirely while snoozed (use with -snooze)")
+	flag.IntVar(&snoozeSaleThreshold, "snooze-sale-threshold", 0, "Sale threshold while snoozed (default: SnoozeSaleThreshold)")
+	flag.IntVar(&snoozePointPercent, "snooze-point-percent", 0, "Point percentage threshold while snoozed (default: SnoozePointPercent)")
+	flag.BoolVar(&unsnooze, "unsnooze", false, "End the current snooze period immediately")
 }
 
 func main() {
+	flag.Usage = utils.Usage
 	flag.Parse()
 	utils.Run(process)
 }
@@ -43,6 +59,10 @@ func process() error {
 		return organizeBookList(cfg, checkerConfigs)
 	}
 
+	if shouldUpdateSnooze() {
+		return updateSnooze(cfg, checkerConfigs)
+	}
+
 	if !checkerConfigs.SaleChecker.Enabled && utils.IsLambda() {
 		log.Printf("SaleChecker is disabled, skipping execution")
 		return nil
@@ -57,6 +77,11 @@ func process() error {
 		}
 	}
 
+	if err := utils.LoadWarmUp(cfg, time.Now()); err != nil {
+		return err
+	}
+	defer utils.SaveWarmUp(cfg)
+
 	originalBooks, err := utils.FetchASINs(cfg, utils.EnvConfig.S3UnprocessedObjectKey)
 	if err != nil {
 		return fmt.Errorf("failed to fetch unprocessed ASINs: %w", err)
@@ -67,15 +92,18 @@ func process() error {
 		return fmt.Errorf("failed to fetch upcoming ASINs: %w", err)
 	}
 
-	allBooks := utils.UniqueASINs(append(originalBooks, upcomingBooks...))
+	allBooks, err := utils.ExpireWatchedBooks(cfg, utils.UniqueASINs(append(originalBooks, upcomingBooks...)), time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to archive expired books: %w", err)
+	}
 	segmentBooks, startIndex, endIndex := getNextProcessingSegment(cfg, allBooks)
 
 	processedBooks, err := checkBooksForSales(cfg, segmentBooks, checkerConfigs)
 	if err != nil {
-		return fmt.Errorf("PA API processing failed: %v", err)
+		return fmt.Errorf("PA API processing failed: %w", err)
 	}
 
-	if err := utils.PutS3Object(cfg, fmt.Sprintf("%d", startIndex+len(processedBooks)), utils.EnvConfig.S3PrevIndexSaleCheckerObjectKey); err != nil {
+	if err := utils.PutObject(cfg, fmt.Sprintf("%d", startIndex+len(processedBooks)), utils.EnvConfig.S3PrevIndexSaleCheckerObjectKey); err != nil {
 		return fmt.Errorf("failed to save progress index: %w", err)
 	}
 
@@ -94,8 +122,8 @@ func process() error {
 		return fmt.Errorf("failed to save unprocessed ASINs: %w", err)
 	}
 
-	if err := utils.UpdateBookGist(checkerConfigs.SaleChecker.GistID, checkerConfigs.SaleChecker.GistFilename, updatedBooks); err != nil {
-		return fmt.Errorf("error update gist: %s", err)
+	if err := updateGist(cfg, updatedBooks, checkerConfigs); err != nil {
+		return fmt.Errorf("error update gist: %w", err)
 	}
 
 	if err := clearUpcomingBooksIfUnchanged(cfg, upcomingBooks); err != nil {
@@ -132,7 +160,7 @@ func organizeBookList(cfg aws.Config, checkerConfigs *utils.CheckerConfigs) erro
 		return fmt.Errorf("failed to save books to S3: %w", err)
 	}
 
-	if err := utils.UpdateBookGist(checkerConfigs.SaleChecker.GistID, checkerConfigs.SaleChecker.GistFilename, books); err != nil {
+	if err := updateGist(cfg, books, checkerConfigs); err != nil {
 		return fmt.Errorf("failed to update gist: %w", err)
 	}
 
@@ -140,6 +168,40 @@ func organizeBookList(cfg aws.Config, checkerConfigs *utils.CheckerConfigs) erro
 	return nil
 }
 
+func shouldUpdateSnooze() bool {
+	return snoozeDuration > 0 || unsnooze
+}
+
+func updateSnooze(cfg aws.Config, checkerConfigs *utils.CheckerConfigs) error {
+	if unsnooze {
+		if err := utils.UnsnoozeSales(cfg); err != nil {
+			return err
+		}
+		fmt.Println("Snooze cleared")
+		return nil
+	}
+
+	now := time.Now()
+	req := utils.SnoozeRequest{
+		Duration:      snoozeDuration,
+		Mute:          snoozeMute,
+		SaleThreshold: snoozeSaleThreshold,
+		PointPercent:  snoozePointPercent,
+	}
+	saleConfig, err := utils.SnoozeSales(cfg, checkerConfigs.SaleChecker, req, now)
+	if err != nil {
+		return err
+	}
+
+	if saleConfig.SnoozeMuteNotifications {
+		fmt.Printf("Sale notifications muted until %s\n", textfmt.TimeJST(saleConfig.SnoozeUntil))
+	} else {
+		saleThreshold, pointPercent := saleConfig.Thresholds(now)
+		fmt.Printf("Sale thresholds raised to %d / %d%% until %s\n", saleThreshold, pointPercent, textfmt.TimeJST(saleConfig.SnoozeUntil))
+	}
+	return nil
+}
+
 func getNextProcessingSegment(cfg aws.Config, books []utils.KindleBook) ([]utils.KindleBook, int, int) {
 	if len(books) == 0 {
 		return books, 0, 0
@@ -150,12 +212,13 @@ func getNextProcessingSegment(cfg aws.Config, books []utils.KindleBook) ([]utils
 		startIndex = 0
 	}
 
-	endIndex := min(startIndex+10, len(books))
+	endIndex := min(startIndex+utils.WarmUpSegmentSize(10), len(books))
 
 	segment := books[startIndex:endIndex]
 
 	log.Printf("Processing books %d-%d of %d total (segment size: %d)",
 		startIndex+1, endIndex, len(books), len(segment))
+	utils.SetRunItem("%d-%d / %d冊", startIndex+1, endIndex, len(books))
 
 	for i, book := range segment {
 		log.Printf("[Queue] %d/%d: %s | %s | %s",
@@ -166,7 +229,7 @@ func getNextProcessingSegment(cfg aws.Config, books []utils.KindleBook) ([]utils
 }
 
 func getLastProcessedIndex(cfg aws.Config) int {
-	data, err := utils.GetS3Object(cfg, utils.EnvConfig.S3PrevIndexSaleCheckerObjectKey)
+	data, err := utils.GetObject(cfg, utils.EnvConfig.S3PrevIndexSaleCheckerObjectKey)
 	if err != nil {
 		return 0
 	}
@@ -179,18 +242,32 @@ func getLastProcessedIndex(cfg aws.Config) int {
 }
 
 func checkBooksForSales(cfg aws.Config, segmentBooks []utils.KindleBook, checkerConfigs *utils.CheckerConfigs) ([]utils.KindleBook, error) {
-	client := utils.CreateClient()
+	client, err := utils.CreateClient()
+	if err != nil {
+		return segmentBooks, err
+	}
 
 	var processedBooks []utils.KindleBook
 
+	var requestedBooks []utils.KindleBook
 	var asins []string
 	for _, book := range segmentBooks {
 		if book.ASIN == "" {
 			utils.AlertToSlack(fmt.Errorf("empty ASIN found in book: Title=%s, URL=%s", book.Title, book.URL), false)
 			continue
 		}
+		if isExcludedByPriceCap(book, checkerConfigs) {
+			log.Printf("Skipping book over the max price cap: %s (%.0f円)", book.Title, book.CurrentPrice)
+			processedBooks = append(processedBooks, book)
+			continue
+		}
+		requestedBooks = append(requestedBooks, book)
 		asins = append(asins, book.ASIN)
 	}
+	if len(asins) == 0 {
+		return processedBooks, nil
+	}
+
 	resp, err := utils.GetItems(cfg, client, asins, checkerConfigs.SaleChecker.GetItemsInitialRetrySeconds, checkerConfigs.SaleChecker.GetItemsPaapiRetryCount)
 	if err != nil {
 		utils.PutMetric(cfg, "KindleBot/SaleChecker", "APIFailure")
@@ -199,10 +276,15 @@ func checkBooksForSales(cfg aws.Config, segmentBooks []utils.KindleBook, checker
 
 	utils.PutMetric(cfg, "KindleBot/SaleChecker", "APISuccess")
 
-	checkMissingASINs(segmentBooks, resp.ItemsResult.Items)
+	checkMissingASINs(requestedBooks, resp.ItemsResult.Items)
+
+	muted := checkerConfigs.SaleChecker.IsMuted(time.Now())
+	if muted {
+		log.Printf("Sale notifications are muted until %s", textfmt.TimeJST(checkerConfigs.SaleChecker.SnoozeUntil))
+	}
 
 	for _, item := range resp.ItemsResult.Items {
-		if !isKindle(item) {
+		if !utils.IsKindleItem(item) {
 			utils.AlertToSlack(fmt.Errorf(strings.TrimSpace(`
 the item category is not a Kindle版.
 ASIN: %s
@@ -216,37 +298,173 @@ URL: %s`),
 
 		book := utils.GetBook(item.ASIN, segmentBooks)
 
-		if item.Offers == nil || item.Offers.Listings == nil || len(*item.Offers.Listings) == 0 || (*item.Offers.Listings)[0].Price == nil {
-			utils.AlertToSlack(fmt.Errorf(strings.TrimSpace(`
-price information not available for item.
-ASIN: %s
-Title: %s
-URL: %s`),
-				item.ASIN, item.ItemInfo.Title.DisplayValue, item.DetailPageURL,
-			), false)
-
+		offer, ok := utils.SelectOffer(item)
+		if !ok {
+			reportMissingOffer(book, item, time.Now())
 			processedBooks = append(processedBooks, book)
 			continue
 		}
 
-		maxPrice := max(book.MaxPrice, (*item.Offers.Listings)[0].Price.Amount)
+		if offer.Count > 1 {
+			log.Printf("[%s] %d offers returned, using %.0f円 from %q", item.ASIN, offer.Count, offer.Price, offer.Merchant)
+		}
+
+		if utils.IsOverPriceCap(offer.Price, checkerConfigs.SaleChecker.MaxPriceCap) {
+			processedBooks = append(processedBooks, flagOverPriceCap(book, item, checkerConfigs))
+			continue
+		}
 
-		if conditions := extractSaleConditions(item, maxPrice, checkerConfigs); len(conditions) > 0 {
-			utils.LogAndNotify(formatSlackMessage(item, conditions), true)
+		maxPrice := max(book.MaxPrice, offer.Price)
+
+		conditions := extractSaleConditions(offer, maxPrice, checkerConfigs)
+		saleMessage := func(rc textfmt.RenderContext) string {
+			return render.Sale(rc, item, book, conditions)
+		}
+		if len(conditions) > 0 && !muted {
+			utils.Notify(cfg, utils.Notification{
+				Kind:        utils.NotificationSale,
+				ReleaseDate: book.ReleaseDate.Time,
+				Public:      true,
+				Render:      saleMessage,
+			})
 		} else {
-			updatedBook := utils.MakeBook(item, maxPrice)
-			if priceChangeMsg := checkPriceChange(book, updatedBook, checkerConfigs); priceChangeMsg != "" {
-				utils.LogAndNotify(priceChangeMsg, true)
+			if len(conditions) > 0 {
+				log.Printf("Muted sale notification: %s", saleMessage(textfmt.RenderContext{Now: time.Now()}))
+			}
+			updatedBook := utils.CarryOverTracking(book, utils.MakeBook(item, maxPrice))
+			if priceChangeMessage := checkPriceChange(book, updatedBook, checkerConfigs); priceChangeMessage != nil {
+				if muted {
+					log.Printf("Muted price change notification: %s", priceChangeMessage(textfmt.RenderContext{Now: time.Now()}))
+				} else {
+					utils.Notify(cfg, utils.Notification{
+						Kind:        utils.NotificationPriceChange,
+						ReleaseDate: updatedBook.ReleaseDate.Time,
+						Public:      true,
+						Render:      priceChangeMessage,
+					})
+				}
 			}
 			processedBooks = append(processedBooks, updatedBook)
 		}
 	}
 
+	if err := checkAudibleEditions(cfg, client, segmentBooks, checkerConfigs); err != nil {
+		utils.AlertToSlack(err, false)
+	}
+
 	return processedBooks, nil
 }
 
-func isKindle(item entity.Item) bool {
-	return item.ItemInfo.Classifications.Binding.DisplayValue == "Kindle版"
+func checkAudibleEditions(cfg aws.Config, client paapi5.Client, books []utils.KindleBook, checkerConfigs *utils.CheckerConfigs) error {
+	var watched []utils.KindleBook
+	for _, book := range books {
+		if book.TrackAudible {
+			watched = append(watched, book)
+		}
+	}
+	if len(watched) == 0 {
+		return nil
+	}
+	if checkerConfigs.AudibleBrowseNodeID == "" {
+		log.Printf("AudibleBrowseNodeID is not set, skipping the Audible search for %d titles", len(watched))
+		return nil
+	}
+
+	audibleMap, err := utils.FetchAudibleNotifiedASINs(cfg)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	found := false
+	for _, book := range watched {
+		series := textfmt.SeriesTitle(book.Title)
+		q := querybuilder.New(querybuilder.Audible(checkerConfigs.AudibleBrowseNodeID), querybuilder.Title(series)).Query(client)
+		res, err := utils.SearchItems(cfg, client, q, checkerConfigs.SaleChecker.GetItemsPaapiRetryCount, checkerConfigs.SaleChecker.GetItemsInitialRetrySeconds)
+		if err != nil {
+			return fmt.Errorf("failed to search Audible editions of %s: %w", series, err)
+		}
+		if res.SearchResult == nil {
+			continue
+		}
+
+		for _, item := range res.SearchResult.Items {
+			if _, exists := audibleMap[item.ASIN]; exists || !isAudibleEditionOf(item, series) {
+				continue
+			}
+
+			n := utils.Notification{
+				Kind:   utils.NotificationAudible,
+				Public: true,
+				Render: func(rc textfmt.RenderContext) string {
+					return render.Audible(rc, item, utils.ItemContributors(item))
+				},
+			}
+			if item.ItemInfo.ProductInfo != nil && item.ItemInfo.ProductInfo.ReleaseDate != nil {
+				n.ReleaseDate = item.ItemInfo.ProductInfo.ReleaseDate.DisplayValue.Time
+			}
+			utils.Notify(cfg, n)
+
+			audibleMap[item.ASIN] = utils.MarkSource(utils.MakeBook(item, 0), utils.SourceSale, now)
+			found = true
+		}
+	}
+
+	if !found {
+		return nil
+	}
+	return utils.SaveAudibleNotifiedASINs(cfg, audibleMap)
+}
+
+func isAudibleEditionOf(item entity.Item, series string) bool {
+	return utils.IsAudibleItem(item) && strings.Contains(item.ItemInfo.Title.DisplayValue, series)
+}
+
+func isExcludedByPriceCap(book utils.KindleBook, checkerConfigs *utils.CheckerConfigs) bool {
+	saleConfig := checkerConfigs.SaleChecker
+	return saleConfig.MaxPriceCapExclude && book.OverPriceCap && utils.IsOverPriceCap(book.CurrentPrice, saleConfig.MaxPriceCap)
+}
+
+func flagOverPriceCap(book utils.KindleBook, item entity.Item, checkerConfigs *utils.CheckerConfigs) utils.KindleBook {
+	updatedBook := utils.CarryOverTracking(book, utils.MakeBook(item, 0))
+	updatedBook.MaxPrice = book.MaxPrice
+	updatedBook.OverPriceCap = true
+
+	if !book.OverPriceCap {
+		action := "flagged (notifications are suppressed until the price drops below the cap)"
+		if checkerConfigs.SaleChecker.MaxPriceCapExclude {
+			action = "excluded from the sale watch"
+		}
+		utils.AlertToSlack(fmt.Errorf(strings.TrimSpace(`
+the item price exceeds the max price cap and was %s.
+ASIN: %s
+Title: %s
+Price: %.0f円 (cap: %d円)
+URL: %s`),
+			action, item.ASIN, updatedBook.Title, updatedBook.CurrentPrice, checkerConfigs.SaleChecker.MaxPriceCap, item.DetailPageURL,
+		), false)
+	}
+
+	return updatedBook
+}
+
+// reportMissingOffer alerts about an item that has no price. Pre-orders are
+// often listed before they are priced, so those are only logged. It reports
+// whether an alert was sent.
+func reportMissingOffer(book utils.KindleBook, item entity.Item, now time.Time) bool {
+	if isPreOrder(book, now) {
+		log.Printf("[%s] Pre-order without a price yet: %s (release: %s)", item.ASIN, book.Title, book.ReleaseDate.Format("2006-01-02"))
+		return false
+	}
+
+	utils.AlertToSlack(fmt.Errorf(strings.TrimSpace(`
+price information not available for item.
+ASIN: %s
+Title: %s
+URL: %s`),
+		item.ASIN, item.ItemInfo.Title.DisplayValue, item.DetailPageURL,
+	), false)
+	return true
 }
 
 func checkMissingASINs(requestedBooks []utils.KindleBook, responseItems []entity.Item) {
@@ -275,51 +493,50 @@ Response count: %d`),
 	}
 }
 
-func extractSaleConditions(item entity.Item, maxPrice float64, checkerConfigs *utils.CheckerConfigs) []string {
-	currentPrice := (*item.Offers.Listings)[0].Price.Amount
-	loyaltyPoints := (*item.Offers.Listings)[0].LoyaltyPoints.Points
+func extractSaleConditions(offer utils.Offer, maxPrice float64, checkerConfigs *utils.CheckerConfigs) []string {
+	currentPrice := offer.Price
+	loyaltyPoints := offer.LoyaltyPoints
+
+	saleThreshold, pointPercent := checkerConfigs.SaleChecker.Thresholds(time.Now())
 
 	var conditions []string
-	if priceDiff := maxPrice - currentPrice; priceDiff >= float64(checkerConfigs.SaleChecker.SaleThreshold) {
-		conditions = append(conditions, fmt.Sprintf("✅ 最高額との価格差 %.0f円", priceDiff))
+	if priceDiff := maxPrice - currentPrice; priceDiff >= float64(saleThreshold) {
+		conditions = append(conditions, render.SaleConditionPriceDiff(priceDiff))
 	}
-	if loyaltyPoints >= checkerConfigs.SaleChecker.SaleThreshold {
-		conditions = append(conditions, fmt.Sprintf("✅ ポイント %dpt", loyaltyPoints))
+	if loyaltyPoints >= saleThreshold {
+		conditions = append(conditions, render.SaleConditionPoints(loyaltyPoints))
 	}
-	if pointPercentValue := float64(loyaltyPoints) / currentPrice * 100; pointPercentValue >= float64(checkerConfigs.SaleChecker.PointPercent) {
-		conditions = append(conditions, fmt.Sprintf("✅ ポイント還元 %.1f%%", pointPercentValue))
+	if pointPercentValue := float64(loyaltyPoints) / currentPrice * 100; pointPercentValue >= float64(pointPercent) {
+		conditions = append(conditions, render.SaleConditionPointPercent(pointPercentValue))
 	}
 
 	return conditions
 }
 
-func formatSlackMessage(item entity.Item, conditions []string) string {
-	return fmt.Sprintf(
-		"📚 セール情報: %s\n条件達成: %s\n%s",
-		item.ItemInfo.Title.DisplayValue,
-		strings.Join(conditions, " "),
-		item.DetailPageURL,
-	)
-}
-
-func checkPriceChange(oldBook, newBook utils.KindleBook, checkerConfigs *utils.CheckerConfigs) string {
+func checkPriceChange(oldBook, newBook utils.KindleBook, checkerConfigs *utils.CheckerConfigs) textfmt.MessageRenderer {
 	if oldBook.CurrentPrice == 0 {
-		return ""
+		return nil
 	}
 
 	priceDiff := newBook.CurrentPrice - oldBook.CurrentPrice
+	if math.Abs(priceDiff) < float64(checkerConfigs.SaleChecker.PriceChangeAmount) {
+		return nil
+	}
 
-	baseMessage := fmt.Sprintf("%s\n価格変動: %.0f円 → %.0f円 (%.0f円)\n%s",
-		newBook.Title, oldBook.CurrentPrice, newBook.CurrentPrice, priceDiff, newBook.URL)
-	if priceDiff >= float64(checkerConfigs.SaleChecker.PriceChangeAmount) {
-		return "📈 プチ値上がり情報: " + baseMessage
-	} else if priceDiff <= -float64(checkerConfigs.SaleChecker.PriceChangeAmount) {
-		return "📉 プチ値下がり情報: " + baseMessage
-	} else {
-		return ""
+	return func(rc textfmt.RenderContext) string {
+		return render.PriceChange(rc, oldBook, newBook)
 	}
 }
 
+func isPreOrder(book utils.KindleBook, now time.Time) bool {
+	return (textfmt.RenderContext{Now: now}).DaysUntil(book.ReleaseDate.Time) > 0
+}
+
+func updateGist(cfg aws.Config, books []utils.KindleBook, checkerConfigs *utils.CheckerConfigs) error {
+	markdown := render.BookList(textfmt.RenderContext{Now: time.Now()}, books)
+	return utils.PublishGist(cfg, checkerConfigs.SaleChecker.GistID, checkerConfigs.SaleChecker.GistFilename, markdown)
+}
+
 func replaceProcessedSegment(allBooks, processedBooks []utils.KindleBook, startIndex, endIndex int) []utils.KindleBook {
 	result := allBooks[:startIndex]
 	result = append(result, processedBooks...)
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/goark/pa-api/entity"
+
+	"kindle_bot/render/textfmt"
+	"kindle_bot/utils"
+)
+
+var jst = time.FixedZone("JST", 9*60*60)
+
+func newItem(t *testing.T, data string) entity.Item {
+	t.Helper()
+	var item entity.Item
+	if err := json.Unmarshal([]byte(data), &item); err != nil {
+		t.Fatal(err)
+	}
+	return item
+}
+
+func TestIsExcludedByPriceCap(t *testing.T) {
+	tests := []struct {
+		name     string
+		exclude  bool
+		book     utils.KindleBook
+		expected bool
+	}{
+		{"flagged and still over the cap", true, utils.KindleBook{CurrentPrice: 6000, OverPriceCap: true}, true},
+		{"exclusion disabled", false, utils.KindleBook{CurrentPrice: 6000, OverPriceCap: true}, false},
+		{"not flagged yet", true, utils.KindleBook{CurrentPrice: 6000}, false},
+		{"flagged but back under the cap", true, utils.KindleBook{CurrentPrice: 5000, OverPriceCap: true}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configs := &utils.CheckerConfigs{SaleChecker: utils.SaleCheckerConfig{MaxPriceCap: 5000, MaxPriceCapExclude: tt.exclude}}
+			if got := isExcludedByPriceCap(tt.book, configs); got != tt.expected {
+				t.Errorf("isExcludedByPriceCap() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFlagOverPriceCap(t *testing.T) {
+	// Mock mode keeps the one-time price cap alert away from Slack.
+	utils.EnvConfig.PAAPIMode = utils.PAAPIModeMock
+	defer func() { utils.EnvConfig.PAAPIMode = "" }()
+
+	item := newItem(t, `{
+		"ASIN": "B0TESTASIN",
+		"DetailPageURL": "https://www.amazon.co.jp/dp/B0TESTASIN",
+		"ItemInfo": {
+			"Title": {"DisplayValue": "全巻セット"},
+			"ProductInfo": {"ReleaseDate": {"DisplayValue": "2024-08-27T00:00:00Z"}}
+		},
+		"Offers": {"Listings": [{"Price": {"Amount": 6000}, "MerchantInfo": {"Name": "Amazon.co.jp"}}]}
+	}`)
+	configs := &utils.CheckerConfigs{SaleChecker: utils.SaleCheckerConfig{MaxPriceCap: 5000}}
+
+	tests := []struct {
+		name string
+		book utils.KindleBook
+	}{
+		{"newly over the cap", utils.KindleBook{ASIN: "B0TESTASIN", MaxPrice: 4000, Source: utils.SourceManual}},
+		{"already flagged", utils.KindleBook{ASIN: "B0TESTASIN", MaxPrice: 4000, Source: utils.SourceManual, OverPriceCap: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := flagOverPriceCap(tt.book, item, configs)
+			if !got.OverPriceCap || got.MaxPrice != 4000 || got.CurrentPrice != 6000 || got.Source != utils.SourceManual {
+				t.Errorf("flagOverPriceCap() = %+v, expected the flag, current price and kept MaxPrice and Source", got)
+			}
+		})
+	}
+}
+
+func TestIsPreOrder(t *testing.T) {
+	now := time.Date(2024, 8, 20, 10, 0, 0, 0, jst)
+
+	tests := []struct {
+		name        string
+		releaseDate time.Time
+		expected    bool
+	}{
+		{"releases tomorrow", time.Date(2024, 8, 21, 0, 0, 0, 0, jst), true},
+		{"releases today", time.Date(2024, 8, 20, 0, 0, 0, 0, jst), false},
+		{"already released", time.Date(2024, 8, 1, 0, 0, 0, 0, jst), false},
+		{"no release date", time.Time{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			book := utils.KindleBook{ReleaseDate: entity.Date{Time: tt.releaseDate}}
+			if got := isPreOrder(book, now); got != tt.expected {
+				t.Errorf("isPreOrder() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCheckPriceChange(t *testing.T) {
+	now := time.Date(2024, 8, 20, 10, 0, 0, 0, jst)
+	nextWeek := entity.Date{Time: time.Date(2024, 8, 27, 0, 0, 0, 0, jst)}
+	lastWeek := entity.Date{Time: time.Date(2024, 8, 13, 0, 0, 0, 0, jst)}
+	configs := &utils.CheckerConfigs{SaleChecker: utils.SaleCheckerConfig{PriceChangeAmount: 50}}
+
+	tests := []struct {
+		name     string
+		oldBook  utils.KindleBook
+		newBook  utils.KindleBook
+		expected string
+	}{
+		{
+			name:     "Below the threshold",
+			oldBook:  utils.KindleBook{CurrentPrice: 500},
+			newBook:  utils.KindleBook{CurrentPrice: 480, ReleaseDate: nextWeek},
+			expected: "",
+		},
+		{
+			name:     "Pre-order without a recorded price",
+			oldBook:  utils.KindleBook{},
+			newBook:  utils.KindleBook{CurrentPrice: 500, ReleaseDate: nextWeek},
+			expected: "",
+		},
+		{
+			name:     "Pre-order price drop",
+			oldBook:  utils.KindleBook{CurrentPrice: 500},
+			newBook:  utils.KindleBook{Title: "テスト 1", URL: "https://amzn.to/x", CurrentPrice: 400, ReleaseDate: nextWeek},
+			expected: "📉 プチ値下がり情報: テスト 1\n価格変動: 500円 → 400円 (-100円)\nhttps://amzn.to/x\n⏳ 発売まであと7日\n🛒 予約注文は発売日までの最低価格で購入できます",
+		},
+		{
+			name:     "Pre-order price rise",
+			oldBook:  utils.KindleBook{CurrentPrice: 400},
+			newBook:  utils.KindleBook{Title: "テスト 1", URL: "https://amzn.to/x", CurrentPrice: 500, ReleaseDate: nextWeek},
+			expected: "📈 プチ値上がり情報: テスト 1\n価格変動: 400円 → 500円 (100円)\nhttps://amzn.to/x\n⏳ 発売まであと7日",
+		},
+		{
+			name:     "Released book",
+			oldBook:  utils.KindleBook{CurrentPrice: 500},
+			newBook:  utils.KindleBook{Title: "テスト 1", URL: "https://amzn.to/x", CurrentPrice: 400, ReleaseDate: lastWeek},
+			expected: "📉 プチ値下がり情報: テスト 1\n価格変動: 500円 → 400円 (-100円)\nhttps://amzn.to/x",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			renderer := checkPriceChange(tt.oldBook, tt.newBook, configs)
+			got := ""
+			if renderer != nil {
+				got = renderer(textfmt.RenderContext{Now: now})
+			}
+			if got != tt.expected {
+				t.Errorf("checkPriceChange() rendered %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestReportMissingOffer(t *testing.T) {
+	// Mock mode keeps the missing price alert away from Slack.
+	utils.EnvConfig.PAAPIMode = utils.PAAPIModeMock
+	defer func() { utils.EnvConfig.PAAPIMode = "" }()
+
+	now := time.Date(2024, 8, 20, 10, 0, 0, 0, jst)
+	item := newItem(t, `{
+		"ASIN": "B0TESTASIN",
+		"DetailPageURL": "https://www.amazon.co.jp/dp/B0TESTASIN",
+		"ItemInfo": {"Title": {"DisplayValue": "テスト 1"}}
+	}`)
+
+	tests := []struct {
+		name        string
+		releaseDate time.Time
+		alerted     bool
+	}{
+		{"unpriced pre-order is kept quietly", time.Date(2024, 8, 27, 0, 0, 0, 0, jst), false},
+		{"released book without a price", time.Date(2024, 8, 13, 0, 0, 0, 0, jst), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			book := utils.KindleBook{ASIN: "B0TESTASIN", ReleaseDate: entity.Date{Time: tt.releaseDate}}
+			if got := reportMissingOffer(book, item, now); got != tt.alerted {
+				t.Errorf("reportMissingOffer() = %v, expected %v", got, tt.alerted)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"kindle_bot/utils"
+)
+
+func main() {
+	flag.Usage = utils.Usage
+	utils.Run(process)
+}
+
+func process() error {
+	cfg, err := utils.InitAWSConfig()
+	if err != nil {
+		return err
+	}
+
+	if _, err := utils.FetchCheckerConfigs(cfg); err != nil {
+		return fmt.Errorf("failed to fetch checker configs: %w", err)
+	}
+
+	if err := utils.RemindCriticalAlerts(cfg, time.Now()); err != nil {
+		log.Println("Error re-alerting critical alerts:", err)
+	}
+
+	sent, err := utils.SendDueNotifications(cfg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if sent == 0 {
+		log.Println("No scheduled notifications are due")
+		return nil
+	}
+
+	utils.SetRunItem("%d件", sent)
+	log.Printf("Sent %d scheduled notifications", sent)
+	return nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"kindle_bot/render"
+	"kindle_bot/render/textfmt"
+	"kindle_bot/utils"
+)
+
+const entriesPerMessage = 20
+
+func main() {
+	flag.Usage = utils.Usage
+	utils.Run(process)
+}
+
+func process() error {
+	cfg, err := utils.InitAWSConfig()
+	if err != nil {
+		return err
+	}
+
+	if _, err := utils.FetchCheckerConfigs(cfg); err != nil {
+		return fmt.Errorf("failed to fetch checker configs: %w", err)
+	}
+
+	if utils.IsOnVacation(time.Now()) {
+		log.Printf("Vacation mode is active until %s, holding the digest", textfmt.TimeJST(utils.VacationUntil()))
+		return nil
+	}
+
+	entries, err := utils.FetchDigestEntries(cfg)
+	if err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		log.Println("No vacation digest entries to deliver")
+		return nil
+	}
+
+	utils.SetRunItem("%d件", len(entries))
+	if err := deliverDigest(entries); err != nil {
+		return err
+	}
+
+	if err := utils.RemoveDigestEntries(cfg, len(entries)); err != nil {
+		return fmt.Errorf("failed to clear vacation digest: %w", err)
+	}
+
+	log.Printf("Delivered %d vacation digest entries", len(entries))
+	return nil
+}
+
+func deliverDigest(entries []utils.DigestEntry) error {
+	for start := 0; start < len(entries); start += entriesPerMessage {
+		end := min(start+entriesPerMessage, len(entries))
+		message := render.VacationDigest(entries[start:end], start, len(entries))
+		if err := utils.PostToSlack(message, utils.EnvConfig.SlackNoticeChannel); err != nil {
+			if start > 0 {
+				return fmt.Errorf("%w: posted %d of %d vacation digest entries, failed to post the rest: %w", utils.ErrPartialSuccess, start, len(entries), err)
+			}
+			return fmt.Errorf("failed to post vacation digest: %w", err)
+		}
+	}
+	return nil
+}
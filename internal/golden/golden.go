@@ -0,0 +1,41 @@
+// Package golden compares rendered text with files under testdata/.
+// Run the tests with -update to rewrite the files after an intended change.
+package golden
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+func Assert(t *testing.T, name, got string) {
+	t.Helper()
+
+	if !utf8.ValidString(got) || strings.ContainsRune(got, utf8.RuneError) {
+		t.Errorf("%s: output contains invalid UTF-8 or U+FFFD:\n%s", name, got)
+	}
+
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%s: %v (run the tests with -update to create it)", name, err)
+	}
+	if got != string(want) {
+		t.Errorf("%s does not match %s\n--- got ---\n%s\n--- want ---\n%s", name, path, got, want)
+	}
+}
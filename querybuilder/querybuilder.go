@@ -0,0 +1,217 @@
+package querybuilder
+
+import (
+	"strings"
+	"time"
+
+	paapi5 "github.com/goark/pa-api"
+	"github.com/goark/pa-api/entity"
+	"github.com/goark/pa-api/query"
+)
+
+const (
+	SearchIndexAll         = "All"
+	SearchIndexKindleStore = "KindleStore"
+	SearchIndexBooks       = "Books"
+
+	SortByNewestArrivals = "NewestArrivals"
+	SortByRelevance      = "Relevance"
+
+	kindleComicsBrowseNodeID = "2293143051"
+	kindleComicsMinPrice     = 22100
+)
+
+type Builder struct {
+	searchIndex  string
+	browseNodeID string
+	sortBy       string
+	keywords     []string
+	author       string
+	title        string
+	minPrice     int
+	maxPrice     int
+	publisher    string
+	releasedFrom time.Time
+	releasedTo   time.Time
+	offers       bool
+}
+
+type Option func(*Builder)
+
+func New(opts ...Option) *Builder {
+	b := &Builder{}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+func KindleComics() Option {
+	return func(b *Builder) {
+		b.searchIndex = SearchIndexKindleStore
+		b.browseNodeID = kindleComicsBrowseNodeID
+		b.sortBy = SortByNewestArrivals
+		b.minPrice = kindleComicsMinPrice
+		b.offers = true
+	}
+}
+
+// Audible searches the Audible audiobook browse node of the marketplace.
+// Results still need a binding check, since PA-API can return related items.
+func Audible(browseNodeID string) Option {
+	return func(b *Builder) {
+		b.searchIndex = SearchIndexAll
+		b.browseNodeID = browseNodeID
+		b.sortBy = SortByNewestArrivals
+	}
+}
+
+func Keywords(keywords ...string) Option {
+	return func(b *Builder) {
+		b.keywords = append(b.keywords, keywords...)
+	}
+}
+
+func Author(name string) Option {
+	return func(b *Builder) {
+		b.author = name
+	}
+}
+
+func Title(title string) Option {
+	return func(b *Builder) {
+		b.title = title
+	}
+}
+
+func SearchIndex(index string) Option {
+	return func(b *Builder) {
+		b.searchIndex = index
+	}
+}
+
+func BrowseNode(id string) Option {
+	return func(b *Builder) {
+		b.browseNodeID = id
+	}
+}
+
+func SortBy(sortBy string) Option {
+	return func(b *Builder) {
+		b.sortBy = sortBy
+	}
+}
+
+func MinPrice(price int) Option {
+	return func(b *Builder) {
+		b.minPrice = price
+	}
+}
+
+func MaxPrice(price int) Option {
+	return func(b *Builder) {
+		b.maxPrice = price
+	}
+}
+
+// PA-API has no publisher or release date parameter; these are applied by Filter.
+func Publisher(name string) Option {
+	return func(b *Builder) {
+		b.publisher = name
+	}
+}
+
+func ReleasedBetween(from, to time.Time) Option {
+	return func(b *Builder) {
+		b.releasedFrom = from
+		b.releasedTo = to
+	}
+}
+
+func WithOffers() Option {
+	return func(b *Builder) {
+		b.offers = true
+	}
+}
+
+func (b *Builder) Query(client paapi5.Client) *query.SearchItems {
+	q := query.NewSearchItems(client.Marketplace(), client.PartnerTag(), client.PartnerType())
+
+	if len(b.keywords) > 0 {
+		q = q.Search(query.Keywords, strings.Join(b.keywords, " "))
+	}
+	if b.author != "" {
+		q = q.Search(query.Author, b.author)
+	}
+	if b.title != "" {
+		q = q.Search(query.Title, b.title)
+	}
+	if b.searchIndex != "" {
+		q = q.Request(query.SearchIndex, b.searchIndex)
+	}
+	if b.sortBy != "" {
+		q = q.Request(query.SortBy, b.sortBy)
+	}
+	if b.browseNodeID != "" {
+		q = q.Request(query.BrowseNodeID, b.browseNodeID)
+	}
+	if b.minPrice > 0 {
+		q = q.Request(query.MinPrice, b.minPrice)
+	}
+	if b.maxPrice > 0 {
+		q = q.Request(query.MaxPrice, b.maxPrice)
+	}
+
+	q = q.EnableItemInfo()
+	if b.offers {
+		q = q.EnableOffers()
+	}
+	return q
+}
+
+func (b *Builder) Filter(items []entity.Item) []entity.Item {
+	var result []entity.Item
+	for _, item := range items {
+		if b.Matches(item) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func (b *Builder) Matches(item entity.Item) bool {
+	if item.ItemInfo == nil {
+		return b.publisher == "" && b.releasedFrom.IsZero() && b.releasedTo.IsZero()
+	}
+
+	if b.publisher != "" && !matchesPublisher(item, b.publisher) {
+		return false
+	}
+
+	if b.releasedFrom.IsZero() && b.releasedTo.IsZero() {
+		return true
+	}
+	if item.ItemInfo.ProductInfo == nil || item.ItemInfo.ProductInfo.ReleaseDate == nil {
+		return false
+	}
+
+	releaseDate := item.ItemInfo.ProductInfo.ReleaseDate.DisplayValue.Time
+	if !b.releasedFrom.IsZero() && releaseDate.Before(b.releasedFrom) {
+		return false
+	}
+	if !b.releasedTo.IsZero() && releaseDate.After(b.releasedTo) {
+		return false
+	}
+	return true
+}
+
+func matchesPublisher(item entity.Item, publisher string) bool {
+	byLine := item.ItemInfo.ByLineInfo
+	if byLine == nil {
+		return false
+	}
+	if byLine.Manufacturer != nil && strings.Contains(byLine.Manufacturer.DisplayValue, publisher) {
+		return true
+	}
+	return byLine.Brand != nil && strings.Contains(byLine.Brand.DisplayValue, publisher)
+}
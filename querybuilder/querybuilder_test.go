@@ -0,0 +1,182 @@
+package querybuilder
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	paapi5 "github.com/goark/pa-api"
+	"github.com/goark/pa-api/entity"
+)
+
+type fakeClient struct{}
+
+func (fakeClient) Marketplace() string { return "www.amazon.co.jp" }
+func (fakeClient) PartnerTag() string  { return "tag-22" }
+func (fakeClient) PartnerType() string { return "Associates" }
+func (fakeClient) Request(paapi5.Query) ([]byte, error) {
+	return nil, nil
+}
+func (fakeClient) RequestContext(context.Context, paapi5.Query) ([]byte, error) {
+	return nil, nil
+}
+
+func TestQueryPayload(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []Option
+		expected  map[string]any
+		absent    []string
+		withOffer bool
+	}{
+		{
+			name: "Kindle comics by author",
+			opts: []Option{KindleComics(), Author("山田太郎")},
+			expected: map[string]any{
+				"Operation":    "SearchItems",
+				"Marketplace":  "www.amazon.co.jp",
+				"PartnerTag":   "tag-22",
+				"PartnerType":  "Associates",
+				"Author":       "山田太郎",
+				"SearchIndex":  "KindleStore",
+				"SortBy":       "NewestArrivals",
+				"BrowseNodeId": "2293143051",
+				"MinPrice":     float64(22100),
+			},
+			absent:    []string{"Keywords", "Title", "MaxPrice"},
+			withOffer: true,
+		},
+		{
+			name: "Kindle comics by title with max price",
+			opts: []Option{KindleComics(), Title("村人ですが何か？"), MaxPrice(50000)},
+			expected: map[string]any{
+				"Title":       "村人ですが何か？",
+				"SearchIndex": "KindleStore",
+				"MinPrice":    float64(22100),
+				"MaxPrice":    float64(50000),
+			},
+			absent:    []string{"Author", "Keywords"},
+			withOffer: true,
+		},
+		{
+			name: "Audible editions by author",
+			opts: []Option{Audible("1234567051"), Author("山田太郎")},
+			expected: map[string]any{
+				"Author":       "山田太郎",
+				"SearchIndex":  "All",
+				"SortBy":       "NewestArrivals",
+				"BrowseNodeId": "1234567051",
+			},
+			absent: []string{"Keywords", "MinPrice"},
+		},
+		{
+			name: "Keywords and author with explicit search index",
+			opts: []Option{Keywords("異世界", "コミック"), Author("山田太郎"), SearchIndex(SearchIndexBooks), SortBy(SortByRelevance)},
+			expected: map[string]any{
+				"Keywords":    "異世界 コミック",
+				"Author":      "山田太郎",
+				"SearchIndex": "Books",
+				"SortBy":      "Relevance",
+			},
+			absent: []string{"BrowseNodeId", "MinPrice", "MaxPrice"},
+		},
+		{
+			name: "Later options override presets",
+			opts: []Option{KindleComics(), Keywords("新刊"), SearchIndex(SearchIndexAll), MinPrice(0)},
+			expected: map[string]any{
+				"Keywords":     "新刊",
+				"SearchIndex":  "All",
+				"BrowseNodeId": "2293143051",
+			},
+			absent:    []string{"MinPrice"},
+			withOffer: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := New(tt.opts...).Query(fakeClient{}).Payload()
+			if err != nil {
+				t.Fatalf("Payload() error: %v", err)
+			}
+
+			var payload map[string]any
+			if err := json.Unmarshal(body, &payload); err != nil {
+				t.Fatalf("invalid payload %s: %v", body, err)
+			}
+
+			for key, expected := range tt.expected {
+				if !reflect.DeepEqual(payload[key], expected) {
+					t.Errorf("payload[%q] = %v, expected %v", key, payload[key], expected)
+				}
+			}
+			for _, key := range tt.absent {
+				if _, exists := payload[key]; exists {
+					t.Errorf("payload[%q] = %v, expected it to be absent", key, payload[key])
+				}
+			}
+
+			resources, _ := payload["Resources"].([]any)
+			hasOffers := slices.Contains(resources, any("Offers.Listings.Price"))
+			if hasOffers != tt.withOffer {
+				t.Errorf("Offers resources enabled = %v, expected %v (resources: %v)", hasOffers, tt.withOffer, resources)
+			}
+			if !slices.Contains(resources, any("ItemInfo.Title")) {
+				t.Errorf("ItemInfo resources missing: %v", resources)
+			}
+		})
+	}
+}
+
+func TestMatches(t *testing.T) {
+	item := decodeItem(t, `{
+		"ASIN": "B000000001",
+		"ItemInfo": {
+			"ByLineInfo": {"Manufacturer": {"DisplayValue": "KADOKAWA"}},
+			"ProductInfo": {"ReleaseDate": {"DisplayValue": "2024-03-15T00:00:00Z"}}
+		}
+	}`)
+	noDate := decodeItem(t, `{
+		"ASIN": "B000000002",
+		"ItemInfo": {"ByLineInfo": {"Brand": {"DisplayValue": "講談社"}}}
+	}`)
+
+	march := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	april := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		item     entity.Item
+		expected bool
+	}{
+		{"No client-side filters", []Option{KindleComics()}, item, true},
+		{"Publisher matches manufacturer", []Option{Publisher("KADOKAWA")}, item, true},
+		{"Publisher matches brand", []Option{Publisher("講談社")}, noDate, true},
+		{"Publisher mismatch", []Option{Publisher("集英社")}, item, false},
+		{"Release date within range", []Option{ReleasedBetween(march, april)}, item, true},
+		{"Release date before range", []Option{ReleasedBetween(april, time.Time{})}, item, false},
+		{"Release date after range", []Option{ReleasedBetween(time.Time{}, march)}, item, false},
+		{"Release date missing", []Option{ReleasedBetween(march, april)}, noDate, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := New(tt.opts...).Matches(tt.item); result != tt.expected {
+				t.Errorf("Matches() = %v, expected %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func decodeItem(t *testing.T, s string) entity.Item {
+	t.Helper()
+	var item entity.Item
+	if err := json.Unmarshal([]byte(s), &item); err != nil {
+		t.Fatalf("invalid item JSON: %v", err)
+	}
+	return item
+}
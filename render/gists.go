@@ -0,0 +1,99 @@
+package render
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"kindle_bot/render/textfmt"
+	"kindle_bot/utils"
+)
+
+const authorChangelogHeading = "# 作家リスト更新履歴"
+
+func BookList(rc textfmt.RenderContext, books []utils.KindleBook) string {
+	var lines []string
+	overCap := 0
+	for _, book := range books {
+		title := book.Title
+		if strings.Contains(title, "モンスターコミックス") {
+			title = title + " 👹"
+		}
+		line := fmt.Sprintf("* [[%s]%s (%.0f円)](%s)", book.ReleaseDate.Format("2006-01-02"), title, book.CurrentPrice, book.URL)
+		if countdown := rc.FormatDaysUntilRelease(book.ReleaseDate.Time); countdown != "" {
+			line += " ⏳" + countdown
+		}
+		if provenance := Provenance(book); provenance != "" {
+			line += " 📌" + provenance
+		}
+		if book.OverPriceCap {
+			line += " ⚠️価格上限超過"
+			overCap++
+		}
+		lines = append(lines, line)
+	}
+
+	header := fmt.Sprintf("## 合計 %d冊", len(books))
+	if overCap > 0 {
+		header += fmt.Sprintf("（うち価格上限超過 %d冊）", overCap)
+	}
+	return header + "\n" + strings.Join(lines, "\n")
+}
+
+func AuthorList(rc textfmt.RenderContext, authors []utils.Author) string {
+	lines := []string{
+		"| 作者 | 最新作 | 発売まで |",
+		"|------|--------|----------|",
+	}
+	for _, author := range authors {
+		lines = append(lines, fmt.Sprintf("| [%s](%s) | [[%s] %s](%s) | %s |",
+			author.Name,
+			author.URL,
+			author.LatestReleaseDate.Format("2006-01-02"),
+			author.LatestReleaseTitle,
+			author.LatestReleaseURL,
+			countdown(rc, author.LatestReleaseDate)))
+	}
+
+	return fmt.Sprintf("## 合計 %d人(最新の単行本発売日降順)\n%s", len(authors), strings.Join(lines, "\n"))
+}
+
+func countdown(rc textfmt.RenderContext, releaseDate time.Time) string {
+	days := rc.DaysUntil(releaseDate)
+	if days < 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d日", days)
+}
+
+// AuthorChangelog renders the net changes of every month before until,
+// newest first. Months without net changes are left out.
+func AuthorChangelog(entries []utils.AuthorAuditEntry, until time.Time) string {
+	var months []time.Time
+	for _, e := range entries {
+		from := utils.MonthStartJST(e.At)
+		if from.Before(until) && !slices.ContainsFunc(months, from.Equal) {
+			months = append(months, from)
+		}
+	}
+	slices.SortFunc(months, func(a, b time.Time) int { return b.Compare(a) })
+
+	sections := []string{authorChangelogHeading}
+	for _, from := range months {
+		added, removed := utils.NetAuthorChanges(entries, from, from.AddDate(0, 1, 0))
+		if len(added) == 0 && len(removed) == 0 {
+			continue
+		}
+
+		lines := []string{"## " + formatMonth(from)}
+		for _, name := range added {
+			lines = append(lines, "* ➕ "+name)
+		}
+		for _, name := range removed {
+			lines = append(lines, "* ➖ "+name)
+		}
+		sections = append(sections, strings.Join(lines, "\n"))
+	}
+	return strings.Join(sections, "\n\n")
+}
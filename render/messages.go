@@ -0,0 +1,217 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/goark/pa-api/entity"
+
+	"kindle_bot/render/textfmt"
+	"kindle_bot/utils"
+)
+
+func Sale(rc textfmt.RenderContext, item entity.Item, book utils.KindleBook, conditions []string) string {
+	message := fmt.Sprintf(
+		"📚 セール情報: %s\n条件達成: %s\n%s",
+		rc.Title(item.ItemInfo.Title.DisplayValue),
+		strings.Join(conditions, " "),
+		item.DetailPageURL,
+	)
+
+	if provenance := Provenance(book); provenance != "" {
+		message += "\n📌 " + provenance
+	}
+	return message
+}
+
+func SaleConditionPriceDiff(priceDiff float64) string {
+	return fmt.Sprintf("✅ 最高額との価格差 %.0f円", priceDiff)
+}
+
+func SaleConditionPoints(points int) string {
+	return fmt.Sprintf("✅ ポイント %dpt", points)
+}
+
+func SaleConditionPointPercent(percent float64) string {
+	return fmt.Sprintf("✅ ポイント還元 %.1f%%", percent)
+}
+
+func Provenance(book utils.KindleBook) string {
+	if book.Source == "" && book.AddedAt == "" {
+		return ""
+	}
+
+	since := book.AddedAt
+	if t, err := time.Parse("2006-01-02", book.AddedAt); err == nil {
+		since = t.Format("2006-01")
+	}
+
+	switch {
+	case book.Source == "":
+		return fmt.Sprintf("%sから追跡中", since)
+	case since == "":
+		return fmt.Sprintf("%s経由で追跡中", sourceLabel(book.Source))
+	default:
+		return fmt.Sprintf("%sから追跡中 (%s経由)", since, sourceLabel(book.Source))
+	}
+}
+
+func sourceLabel(source string) string {
+	switch source {
+	case utils.SourceNewRelease:
+		return "新刊チェック"
+	case utils.SourcePaperToKindle:
+		return "紙書籍チェック"
+	case utils.SourceSale:
+		return "セールチェック"
+	case utils.SourceManual:
+		return "手動追加"
+	case utils.SourceImport:
+		return "インポート"
+	default:
+		return source
+	}
+}
+
+func PriceChange(rc textfmt.RenderContext, oldBook, newBook utils.KindleBook) string {
+	priceDiff := newBook.CurrentPrice - oldBook.CurrentPrice
+
+	prefix := "📈 プチ値上がり情報: "
+	if priceDiff < 0 {
+		prefix = "📉 プチ値下がり情報: "
+	}
+
+	message := prefix + fmt.Sprintf("%s\n価格変動: %.0f円 → %.0f円 (%.0f円)\n%s",
+		rc.Title(newBook.Title), oldBook.CurrentPrice, newBook.CurrentPrice, priceDiff, newBook.URL)
+
+	if rc.DaysUntil(newBook.ReleaseDate.Time) > 0 {
+		message += "\n⏳ " + rc.FormatDaysUntilRelease(newBook.ReleaseDate.Time)
+		if priceDiff < 0 {
+			message += "\n🛒 予約注文は発売日までの最低価格で購入できます"
+		}
+	}
+	return message
+}
+
+func NewRelease(rc textfmt.RenderContext, item entity.Item, authorName string) string {
+	releaseDate := item.ItemInfo.ProductInfo.ReleaseDate.DisplayValue
+	date := releaseDate.Format("2006-01-02")
+	if countdown := rc.FormatDaysUntilRelease(releaseDate.Time); countdown != "" {
+		date += fmt.Sprintf(" (%s)", countdown)
+	}
+
+	return fmt.Sprintf(strings.TrimSpace(`
+📚 新刊予定があります: %s
+作者: %s
+発売日: %s
+ASIN: %s
+%s`),
+		rc.Title(item.ItemInfo.Title.DisplayValue),
+		authorName,
+		date,
+		item.ASIN,
+		item.DetailPageURL,
+	)
+}
+
+func Audible(rc textfmt.RenderContext, item entity.Item, authorName string) string {
+	releaseDate := "不明"
+	if item.ItemInfo.ProductInfo.ReleaseDate != nil {
+		releaseDate = item.ItemInfo.ProductInfo.ReleaseDate.DisplayValue.Format("2006-01-02")
+	}
+	return fmt.Sprintf(strings.TrimSpace(`
+🎧 オーディオブック版があります: %s
+作者: %s
+配信日: %s
+ASIN: %s
+%s`),
+		rc.Title(item.ItemInfo.Title.DisplayValue),
+		authorName,
+		releaseDate,
+		item.ASIN,
+		item.DetailPageURL,
+	)
+}
+
+func PaperToKindle(rc textfmt.RenderContext, paper utils.KindleBook, kindle entity.Item) string {
+	offer, _ := utils.SelectOffer(kindle)
+	message := fmt.Sprintf(strings.TrimSpace(`
+📚 新刊予定があります: %s
+📕 紙書籍(%.0f円): %s
+📱 電子書籍(%.0f円): %s`),
+		rc.Title(kindle.ItemInfo.Title.DisplayValue),
+		paper.CurrentPrice,
+		paper.URL,
+		offer.Price,
+		kindle.DetailPageURL,
+	)
+
+	if countdown := rc.FormatDaysUntilRelease(kindle.ItemInfo.ProductInfo.ReleaseDate.DisplayValue.Time); countdown != "" {
+		message += "\n⏳ " + countdown
+	}
+	return message
+}
+
+func ReleaseToday(rc textfmt.RenderContext, book utils.KindleBook) string {
+	return fmt.Sprintf("📚 本日発売の書籍\n%s\n%s", rc.Title(book.Title), book.URL)
+}
+
+func VacationDigest(entries []utils.DigestEntry, offset, total int) string {
+	var lines []string
+	if offset == 0 {
+		lines = append(lines, fmt.Sprintf("🏖️ 休暇中の通知まとめ (%d件)", total))
+	}
+
+	for i, entry := range entries {
+		lines = append(lines, fmt.Sprintf("--- %d/%d [%s] %s ---\n%s",
+			offset+i+1, total, entry.Kind, textfmt.TimeJST(entry.CreatedAt), entry.Message))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// AuthorChangelogToot lists the names of both sections within maxLength
+// characters, replacing the names that do not fit with "ほかN名".
+func AuthorChangelogToot(month time.Time, added, removed []string, maxLength int) string {
+	lines := []string{fmt.Sprintf("📚 %sの作家リスト更新", formatMonth(month))}
+	if len(added) > 0 {
+		lines = append(lines, fmt.Sprintf("➕ 追加 (%d名)", len(added)), "")
+	}
+	if len(removed) > 0 {
+		lines = append(lines, fmt.Sprintf("➖ 削除 (%d名)", len(removed)), "")
+	}
+
+	budget := maxLength - len([]rune(strings.Join(lines, "\n")))
+	if len(added) > 0 && len(removed) > 0 {
+		budget /= 2
+	}
+
+	i := 2
+	if len(added) > 0 {
+		lines[i] = joinNames(added, budget)
+		i += 2
+	}
+	if len(removed) > 0 {
+		lines[i] = joinNames(removed, budget)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func joinNames(names []string, budget int) string {
+	joined := strings.Join(names, "、")
+	if len([]rune(joined)) <= budget {
+		return joined
+	}
+
+	for n := len(names) - 1; n > 0; n-- {
+		s := fmt.Sprintf("%s ほか%d名", strings.Join(names[:n], "、"), len(names)-n)
+		if len([]rune(s)) <= budget {
+			return s
+		}
+	}
+	return fmt.Sprintf("%d名", len(names))
+}
+
+func formatMonth(t time.Time) string {
+	return fmt.Sprintf("%d年%d月", t.Year(), t.Month())
+}
@@ -0,0 +1,114 @@
+package render
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/goark/pa-api/entity"
+
+	"kindle_bot/internal/golden"
+	"kindle_bot/render/textfmt"
+	"kindle_bot/utils"
+)
+
+var jst = time.FixedZone("JST", 9*60*60)
+
+func newItem(t *testing.T, data string) entity.Item {
+	t.Helper()
+	var item entity.Item
+	if err := json.Unmarshal([]byte(data), &item); err != nil {
+		t.Fatal(err)
+	}
+	return item
+}
+
+func newBook(title, releaseDate string, price float64) utils.KindleBook {
+	date, _ := time.ParseInLocation("2006-01-02", releaseDate, jst)
+	return utils.KindleBook{
+		ASIN:         "B0TESTASIN",
+		Title:        title,
+		ReleaseDate:  entity.Date{Time: date},
+		CurrentPrice: price,
+		URL:          "https://www.amazon.co.jp/dp/B0TESTASIN",
+	}
+}
+
+func TestRender(t *testing.T) {
+	rc := textfmt.RenderContext{Now: time.Date(2024, 8, 20, 9, 0, 0, 0, jst)}
+	item := newItem(t, `{
+		"ASIN": "B0TESTASIN",
+		"DetailPageURL": "https://www.amazon.co.jp/dp/B0TESTASIN",
+		"ItemInfo": {
+			"Title": {"DisplayValue": "葬送のフリーレン（14）"},
+			"ProductInfo": {"ReleaseDate": {"DisplayValue": "2024-08-27T00:00:00Z"}}
+		},
+		"Offers": {"Listings": [{"Price": {"Amount": 528}, "MerchantInfo": {"Name": "Amazon.co.jp"}}]}
+	}`)
+
+	tracked := newBook("葬送のフリーレン（14）", "2024-08-27", 528)
+	tracked.Source = utils.SourcePaperToKindle
+	tracked.AddedAt = "2024-05-01"
+	released := newBook("ダンジョン飯 ワールドガイド 冒険者バイブル 完全版", "2024-08-01", 1100)
+	monster := newBook("異世界おじさん（12） (モンスターコミックス)", "2024-09-05", 693)
+	monster.OverPriceCap = true
+
+	authors := []utils.Author{
+		{
+			Name:               "山田鐘人",
+			URL:                "https://www.amazon.co.jp/author/yamada",
+			LatestReleaseDate:  time.Date(2024, 8, 27, 0, 0, 0, 0, jst),
+			LatestReleaseTitle: "葬送のフリーレン（14）",
+			LatestReleaseURL:   "https://www.amazon.co.jp/dp/B0TESTASIN",
+		},
+		{
+			Name:               "九井諒子",
+			URL:                "https://www.amazon.co.jp/author/kui",
+			LatestReleaseDate:  time.Date(2024, 2, 15, 0, 0, 0, 0, jst),
+			LatestReleaseTitle: "ダンジョン飯 ワールドガイド",
+			LatestReleaseURL:   "https://www.amazon.co.jp/dp/B0OTHERASIN",
+		},
+	}
+
+	digest := []utils.DigestEntry{
+		{Kind: "sale", Message: "📚 セール情報: 葬送のフリーレン（14）", CreatedAt: time.Date(2024, 8, 18, 10, 30, 0, 0, jst)},
+		{Kind: "new-release", Message: "📚 新刊予定があります: ダンジョン飯", CreatedAt: time.Date(2024, 8, 19, 22, 5, 0, 0, jst)},
+	}
+
+	audit := []utils.AuthorAuditEntry{
+		{At: time.Date(2024, 6, 3, 12, 0, 0, 0, jst), Action: utils.AuditActionAdded, Name: "山田鐘人"},
+		{At: time.Date(2024, 7, 10, 12, 0, 0, 0, jst), Action: utils.AuditActionAdded, Name: "九井諒子"},
+		{At: time.Date(2024, 7, 12, 12, 0, 0, 0, jst), Action: utils.AuditActionRemoved, Name: "石田スイ"},
+		{At: time.Date(2024, 8, 1, 12, 0, 0, 0, jst), Action: utils.AuditActionAdded, Name: "藤本タツキ"},
+	}
+
+	names := []string{"山田鐘人", "九井諒子", "藤本タツキ", "石田スイ", "荒川弘", "尾田栄一郎"}
+
+	tests := []struct {
+		name string
+		got  string
+	}{
+		{"sale", Sale(rc, item, tracked, []string{SaleConditionPriceDiff(300), SaleConditionPoints(150), SaleConditionPointPercent(28.4)})},
+		{"sale_truncated_title", Sale(textfmt.RenderContext{Now: rc.Now, TitleMaxLength: 8}, item, utils.KindleBook{}, []string{SaleConditionPoints(150)})},
+		{"price_drop_preorder", PriceChange(rc, tracked, newBook(tracked.Title, "2024-08-27", 480))},
+		{"price_rise_released", PriceChange(rc, released, newBook(released.Title, "2024-08-01", 1200))},
+		{"new_release", NewRelease(rc, item, "山田鐘人")},
+		{"new_release_past_date", NewRelease(textfmt.RenderContext{Now: time.Date(2024, 9, 1, 9, 0, 0, 0, jst)}, item, "山田鐘人")},
+		{"audible", Audible(rc, item, "山田鐘人")},
+		{"paper_to_kindle", PaperToKindle(rc, newBook("葬送のフリーレン（14） (少年サンデーコミックス)", "2024-08-27", 594), item)},
+		{"release_today", ReleaseToday(rc, released)},
+		{"vacation_digest_first", VacationDigest(digest, 0, 3)},
+		{"vacation_digest_continued", VacationDigest(digest[1:], 2, 3)},
+		{"author_changelog_toot", AuthorChangelogToot(time.Date(2024, 7, 1, 0, 0, 0, 0, jst), names[:2], names[3:4], 500)},
+		{"author_changelog_toot_truncated", AuthorChangelogToot(time.Date(2024, 7, 1, 0, 0, 0, 0, jst), names, names, 70)},
+		{"book_list", BookList(rc, []utils.KindleBook{tracked, released, monster})},
+		{"author_list", AuthorList(rc, authors)},
+		{"author_changelog", AuthorChangelog(audit, time.Date(2024, 8, 1, 0, 0, 0, 0, jst))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			golden.Assert(t, tt.name, tt.got)
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package textfmt
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	approvalPreviewMaxLines = 40
+	approvalPreviewMaxChars = 2500
+
+	runHistoryRows           = 20
+	runHistoryErrorMaxLength = 80
+)
+
+func CriticalAlert(mentionUserID, id, source, message string, raisedAt time.Time, alertCount int) string {
+	header := "🚨 重大なエラー"
+	if alertCount > 0 {
+		header = fmt.Sprintf("🚨 重大なエラー (未確認・%d回目の通知)", alertCount+1)
+	}
+
+	return fmt.Sprintf("<@%s> %s: %s\n発生: %s (%s)\n```%s```\n確認後に「確認済み」を押すか `go run ./cmd/admin ack %s` を実行してください",
+		mentionUserID, header, id, TimeJST(raisedAt), source, message, id)
+}
+
+func ApprovalPreview(target, name, checker string, added, removed int, removedLines, addedLines []string) string {
+	return fmt.Sprintf("📝 承認待ちの変更: %s `%s` (%s)\n+%d行 / -%d行\n```%s```",
+		target, name, checker, added, removed, diffPreview(removedLines, addedLines))
+}
+
+func ApprovalResult(target, name string, added, removed int, result string) string {
+	return fmt.Sprintf("📝 %s `%s` (+%d行 / -%d行)\n%s", target, name, added, removed, result)
+}
+
+func diffPreview(removedLines, addedLines []string) string {
+	var lines []string
+	for _, line := range removedLines {
+		lines = append(lines, "- "+line)
+	}
+	for _, line := range addedLines {
+		lines = append(lines, "+ "+line)
+	}
+
+	preview := strings.Join(lines[:min(approvalPreviewMaxLines, len(lines))], "\n")
+	if runes := []rune(preview); len(runes) > approvalPreviewMaxChars {
+		preview = string(runes[:approvalPreviewMaxChars])
+	}
+	if len(lines) > approvalPreviewMaxLines || len([]rune(preview)) == approvalPreviewMaxChars {
+		preview += fmt.Sprintf("\n…（全%d行）", len(lines))
+	}
+	return preview
+}
+
+func WatchExpired(rc RenderContext, author bool, name, url, watchUntil string) string {
+	label := "書籍"
+	if author {
+		label = "著者"
+	}
+
+	message := fmt.Sprintf("⏰ 監視期限（%s）を過ぎたため%sをアーカイブしました\n%s", watchUntil, label, rc.Title(name))
+	if url != "" {
+		message += "\n" + url
+	}
+	return message
+}
+
+// RunRow is one run as shown in the run history gist.
+type RunRow struct {
+	Checker       string
+	StartedAt     time.Time
+	Duration      time.Duration
+	Item          string
+	Failed        bool
+	Error         string
+	Notifications int
+}
+
+func RunHistory(rows []RunRow, now time.Time) string {
+	byChecker := make(map[string][]RunRow)
+	for _, r := range rows {
+		byChecker[r.Checker] = append(byChecker[r.Checker], r)
+	}
+
+	var checkers []string
+	for checker := range byChecker {
+		checkers = append(checkers, checker)
+	}
+	sort.Strings(checkers)
+
+	var sections []string
+	for _, checker := range checkers {
+		runs := slices.Clone(byChecker[checker])
+		sort.Slice(runs, func(i, j int) bool {
+			return runs[i].StartedAt.After(runs[j].StartedAt)
+		})
+
+		failures := 0
+		for _, r := range runs {
+			if r.Failed {
+				failures++
+			}
+		}
+
+		lines := []string{
+			fmt.Sprintf("## %s (直近%d回中 失敗%d回)", checker, len(runs), failures),
+			"| 開始 | 対象 | 結果 | 通知 | 所要時間 |",
+			"|------|------|------|------|----------|",
+		}
+		for _, r := range runs[:min(runHistoryRows, len(runs))] {
+			lines = append(lines, fmt.Sprintf("| %s | %s | %s | %d | %.1fs |",
+				TimeJST(r.StartedAt),
+				escapeTableCell(r.Item),
+				runOutcome(r),
+				r.Notifications,
+				r.Duration.Seconds()))
+		}
+		sections = append(sections, strings.Join(lines, "\n"))
+	}
+
+	return fmt.Sprintf("# 実行履歴\n更新: %s\n\n%s", TimeJST(now), strings.Join(sections, "\n\n"))
+}
+
+func runOutcome(r RunRow) string {
+	if !r.Failed {
+		return "✅"
+	}
+
+	message := strings.SplitN(r.Error, "\n", 2)[0]
+	if runes := []rune(message); len(runes) > runHistoryErrorMaxLength {
+		message = string(runes[:runHistoryErrorMaxLength]) + "…"
+	}
+	return "❌ " + escapeTableCell(message)
+}
+
+func escapeTableCell(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "|", "\\|"), "\n", " ")
+}
@@ -0,0 +1,45 @@
+// Package textfmt holds the render context and the text helpers shared by
+// utils and render, plus the messages that utils posts itself such as
+// critical alerts and approval previews. It must not import utils.
+package textfmt
+
+import (
+	"fmt"
+	"time"
+)
+
+var jst = time.FixedZone("JST", 9*60*60)
+
+type RenderContext struct {
+	Now            time.Time
+	TitleMaxLength int
+}
+
+type MessageRenderer func(rc RenderContext) string
+
+func (rc RenderContext) DaysUntil(date time.Time) int {
+	y1, m1, d1 := rc.Now.In(jst).Date()
+	y2, m2, d2 := date.In(jst).Date()
+	from := time.Date(y1, m1, d1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(y2, m2, d2, 0, 0, 0, 0, time.UTC)
+	return int(to.Sub(from).Hours() / 24)
+}
+
+func (rc RenderContext) FormatDaysUntilRelease(releaseDate time.Time) string {
+	switch days := rc.DaysUntil(releaseDate); {
+	case days > 0:
+		return fmt.Sprintf("発売まであと%d日", days)
+	case days == 0:
+		return "本日発売"
+	default:
+		return ""
+	}
+}
+
+func (rc RenderContext) Title(title string) string {
+	return TruncateTitle(title, rc.TitleMaxLength)
+}
+
+func TimeJST(t time.Time) string {
+	return t.In(jst).Format("2006-01-02 15:04:05")
+}
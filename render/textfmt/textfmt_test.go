@@ -0,0 +1,79 @@
+package textfmt
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"kindle_bot/internal/golden"
+)
+
+func TestRenderContextDaysUntil(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+	releaseDay := time.Date(2024, 8, 27, 0, 0, 0, 0, jst)
+
+	tests := []struct {
+		name      string
+		now       time.Time
+		date      time.Time
+		expected  int
+		countdown string
+	}{
+		{"Days ahead", time.Date(2024, 8, 20, 9, 0, 0, 0, jst), releaseDay, 7, "発売まであと7日"},
+		{"Late evening counts as the same day", time.Date(2024, 8, 26, 23, 59, 0, 0, jst), releaseDay, 1, "発売まであと1日"},
+		{"Release day", time.Date(2024, 8, 27, 18, 0, 0, 0, jst), releaseDay, 0, "本日発売"},
+		{"Already released", time.Date(2024, 8, 28, 0, 30, 0, 0, jst), releaseDay, -1, ""},
+		{"UTC now before JST midnight", time.Date(2024, 8, 26, 15, 30, 0, 0, time.UTC), releaseDay, 0, "本日発売"},
+		{"UTC release date", time.Date(2024, 8, 20, 9, 0, 0, 0, jst), time.Date(2024, 8, 27, 0, 0, 0, 0, time.UTC), 7, "発売まであと7日"},
+		{"Across a month boundary", time.Date(2024, 1, 31, 9, 0, 0, 0, jst), time.Date(2024, 3, 1, 0, 0, 0, 0, jst), 30, "発売まであと30日"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc := RenderContext{Now: tt.now}
+			if got := rc.DaysUntil(tt.date); got != tt.expected {
+				t.Errorf("DaysUntil() = %d, expected %d", got, tt.expected)
+			}
+			if got := rc.FormatDaysUntilRelease(tt.date); got != tt.countdown {
+				t.Errorf("FormatDaysUntilRelease() = %q, expected %q", got, tt.countdown)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	now := time.Date(2024, 8, 20, 9, 0, 0, 0, jst)
+	rc := RenderContext{Now: now}
+
+	var longDiff []string
+	for i := range 45 {
+		longDiff = append(longDiff, fmt.Sprintf(`{"ASIN": "B0TEST%04d"}`, i))
+	}
+
+	runs := []RunRow{
+		{Checker: "sale-checker", StartedAt: now.Add(-4 * time.Minute), Duration: 1500 * time.Millisecond, Item: "1-10 / 120冊", Notifications: 1},
+		{Checker: "sale-checker", StartedAt: now.Add(-2 * time.Minute), Duration: 800 * time.Millisecond, Item: "a|b", Failed: true, Error: "PA API processing failed: " + strings.Repeat("429 ", 30) + "\nstack"},
+		{Checker: "new-release-checker", StartedAt: now.Add(-time.Minute), Duration: 3200 * time.Millisecond, Item: "山田鐘人"},
+	}
+
+	tests := []struct {
+		name string
+		got  string
+	}{
+		{"critical_alert", CriticalAlert("U0TEST", "shrink-guard:unprocessed.json", "admin", "pushed with -force: 120 → 10 entries", now.Add(-time.Hour), 0)},
+		{"critical_alert_repeated", CriticalAlert("U0TEST", "circuit-breaker:sale-checker", "sale-checker", "PA-API quota exceeded in 3 consecutive runs", now.Add(-25*time.Hour), 2)},
+		{"approval_preview", ApprovalPreview("dataset", "authors.json", "admin", 1, 2, []string{`{"Name": "石田スイ"}`, `{"Name": "荒川弘"}`}, []string{`{"Name": "藤本タツキ"}`})},
+		{"approval_preview_truncated", ApprovalPreview("gist", "books.md", "sale-checker", 45, 0, nil, longDiff)},
+		{"approval_result", ApprovalResult("gist", "books.md", 45, 0, "✅ shinderuman が承認し、反映しました")},
+		{"watch_expired_book", WatchExpired(rc, false, "葬送のフリーレン（14）", "https://www.amazon.co.jp/dp/B0TESTASIN", "2024-08-19")},
+		{"watch_expired_author", WatchExpired(RenderContext{Now: now, TitleMaxLength: 4}, true, "九井諒子（原作）", "", "2024-08-19")},
+		{"run_history", RunHistory(runs, now)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			golden.Assert(t, tt.name, tt.got)
+		})
+	}
+}
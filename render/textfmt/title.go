@@ -0,0 +1,86 @@
+package textfmt
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	titleEllipsis       = "…"
+	titleTrailingCutset = " 　：:"
+)
+
+var (
+	trailingLabelRegex  = regexp.MustCompile(`[\s　]*[\(（][^\(\)（）]*[\)）]$`)
+	numericLabelRegex   = regexp.MustCompile(`^[\s　]*[\(（][0-9０-９]+[\)）]$`)
+	trailingVolumeRegex = regexp.MustCompile(`[\s　]*(第?[0-9０-９]+巻?|[\(（][0-9０-９]+[\)）])$`)
+	subtitleDelimiters  = []string{"～", "〜", "~", "―", "：", ":", " - ", "【"}
+	titleEllipsisLength = utf8.RuneCountInString(titleEllipsis)
+)
+
+func TruncateTitle(title string, maxLength int) string {
+	if maxLength <= 0 || utf8.RuneCountInString(title) <= maxLength {
+		return title
+	}
+
+	head := stripTrailingLabels(title)
+	if utf8.RuneCountInString(head) <= maxLength {
+		return head
+	}
+
+	volume := ""
+	if loc := trailingVolumeRegex.FindStringSubmatchIndex(head); loc != nil {
+		volume = head[loc[2]:loc[3]]
+		head = stripTrailingLabels(strings.TrimRight(head[:loc[0]], titleTrailingCutset))
+	}
+
+	suffix := volume
+	if volume != "" && !strings.HasPrefix(volume, "(") && !strings.HasPrefix(volume, "（") {
+		suffix = " " + volume
+	}
+
+	if candidate := head + suffix; utf8.RuneCountInString(candidate) <= maxLength {
+		return candidate
+	}
+
+	series := head
+	for _, d := range subtitleDelimiters {
+		if i := strings.Index(series, d); i > 0 {
+			series = series[:i]
+		}
+	}
+	series = strings.TrimRight(series, titleTrailingCutset)
+
+	if series != head {
+		if candidate := series + titleEllipsis + suffix; utf8.RuneCountInString(candidate) <= maxLength {
+			return candidate
+		}
+	}
+
+	keep := maxLength - titleEllipsisLength - utf8.RuneCountInString(suffix)
+	if keep < 1 {
+		return string([]rune(title)[:maxLength-titleEllipsisLength]) + titleEllipsis
+	}
+	return string([]rune(series)[:min(keep, utf8.RuneCountInString(series))]) + titleEllipsis + suffix
+}
+
+func stripTrailingLabels(title string) string {
+	for {
+		loc := trailingLabelRegex.FindStringIndex(title)
+		if loc == nil || loc[0] == 0 || numericLabelRegex.MatchString(title[loc[0]:]) {
+			return title
+		}
+		title = strings.TrimRight(title[:loc[0]], titleTrailingCutset)
+	}
+}
+
+// SeriesTitle drops the publisher labels and the volume number, leaving a
+// title that also matches other editions of the series.
+func SeriesTitle(title string) string {
+	head := stripTrailingLabels(title)
+	if loc := trailingVolumeRegex.FindStringIndex(head); loc != nil && loc[0] > 0 {
+		head = stripTrailingLabels(strings.TrimRight(head[:loc[0]], titleTrailingCutset))
+	}
+	return head
+}
@@ -0,0 +1,87 @@
+package textfmt
+
+import (
+	"testing"
+)
+
+func TestTruncateTitle(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		maxLength int
+		expected  string
+	}{
+		{
+			name:      "Keep short title as is",
+			input:     "監禁王　６ (ドラゴンコミックスエイジ)",
+			maxLength: 30,
+			expected:  "監禁王　６ (ドラゴンコミックスエイジ)",
+		},
+		{
+			name:      "Unlimited length",
+			input:     "村人ですが何か？(16) (ドラゴンコミックスエイジ)",
+			maxLength: 0,
+			expected:  "村人ですが何か？(16) (ドラゴンコミックスエイジ)",
+		},
+		{
+			name:      "Drop publisher label first",
+			input:     "村人ですが何か？(16) (ドラゴンコミックスエイジ)",
+			maxLength: 12,
+			expected:  "村人ですが何か？(16)",
+		},
+		{
+			name:      "Elide subtitle after volume number",
+			input:     "左遷された無能王子は実力を隠したい6 ~二度転生した最強賢者、今世では楽したいので手を抜いてたら、王家を追放された。今更帰ってこいと言われても遅い、領民に実力がバレて、実家に帰してくれないから……~ (電撃コミックスNEXT)",
+			maxLength: 30,
+			expected:  "左遷された無能王子は実力を隠したい6…",
+		},
+		{
+			name:      "Elide subtitle and keep trailing volume",
+			input:     "異世界クラフトぐらし～自由気ままな生産職のほのぼのスローライフ～（コミック） ： 8 (モンスターコミックス)",
+			maxLength: 20,
+			expected:  "異世界クラフトぐらし… 8",
+		},
+		{
+			name:      "Elide bracketed edition name",
+			input:     "悪役令嬢の兄に転生しました【電子単行本】　7 (ヤングチャンピオン・コミックス)",
+			maxLength: 20,
+			expected:  "悪役令嬢の兄に転生しました… 7",
+		},
+		{
+			name:      "Hard truncate series without subtitle",
+			input:     "とても長いタイトルでサブタイトルの区切りがまったく存在しない作品 3",
+			maxLength: 10,
+			expected:  "とても長いタイ… 3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := TruncateTitle(tt.input, tt.maxLength)
+			if result != tt.expected {
+				t.Errorf("TruncateTitle(%q, %d) = %q, expected %q", tt.input, tt.maxLength, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSeriesTitle(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"葬送のフリーレン（14） (少年サンデーコミックス)", "葬送のフリーレン"},
+		{"村人ですが何か？(16) (ドラゴンコミックスエイジ)", "村人ですが何か？"},
+		{"異世界クラフトぐらし 8巻", "異世界クラフトぐらし"},
+		{"ダンジョン飯 ワールドガイド 冒険者バイブル 完全版", "ダンジョン飯 ワールドガイド 冒険者バイブル 完全版"},
+		{"(12)", "(12)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if result := SeriesTitle(tt.input); result != tt.expected {
+				t.Errorf("SeriesTitle(%q) = %q, expected %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
@@ -5,7 +5,7 @@
 
 echo "Building all commands..."
 
-commands=("new-release-checker" "paper-to-kindle-checker" "sale-checker" "release-notifier")
+commands=("new-release-checker" "paper-to-kindle-checker" "sale-checker" "release-notifier" "vacation-digest" "author-changelog" "approval-handler" "scheduled-notifier" "admin")
 failed_commands=()
 
 for cmd in "${commands[@]}"; do
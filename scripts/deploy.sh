@@ -25,6 +25,10 @@ Available functions:
   new-release-checker       Deploy new-release-checker
   sale-checker              Deploy sale-checker
   release-notifier          Deploy release-notifier
+  vacation-digest           Deploy vacation-digest
+  author-changelog          Deploy author-changelog
+  approval-handler          Deploy approval-handler
+  scheduled-notifier        Deploy scheduled-notifier
   all                       Deploy all functions
 
 Options:
@@ -50,7 +54,7 @@ build_function() {
     export CGO_ENABLED=0
     
     # Build the binary
-    go build -ldflags="-s -w" -o bootstrap "$source_path"
+    go build -ldflags="-s -w -X kindle_bot/utils.BuildID=$(git rev-parse --short HEAD 2>/dev/null || echo unknown)-$(date +%Y%m%d%H%M%S)" -o bootstrap "$source_path"
     
     # Create zip file
     zip -r lambda.zip bootstrap
@@ -275,6 +279,22 @@ parse_arguments() {
                 FUNCTION="release-notifier"
                 shift
                 ;;
+            vacation-digest)
+                FUNCTION="vacation-digest"
+                shift
+                ;;
+            author-changelog)
+                FUNCTION="author-changelog"
+                shift
+                ;;
+            approval-handler)
+                FUNCTION="approval-handler"
+                shift
+                ;;
+            scheduled-notifier)
+                FUNCTION="scheduled-notifier"
+                shift
+                ;;
             all)
                 FUNCTION="all"
                 shift
@@ -318,12 +338,28 @@ deploy_selected_function() {
         release-notifier)
             process_function "cmd/release-notifier/main.go" "$RELEASE_NOTIFIER" "$BUILD_ONLY"
             ;;
+        vacation-digest)
+            process_function "cmd/vacation-digest/main.go" "$VACATION_DIGEST" "$BUILD_ONLY"
+            ;;
+        author-changelog)
+            process_function "cmd/author-changelog/main.go" "$AUTHOR_CHANGELOG" "$BUILD_ONLY"
+            ;;
+        approval-handler)
+            process_function "cmd/approval-handler/main.go" "$APPROVAL_HANDLER" "$BUILD_ONLY"
+            ;;
+        scheduled-notifier)
+            process_function "cmd/scheduled-notifier/main.go" "$SCHEDULED_NOTIFIER" "$BUILD_ONLY"
+            ;;
         all)
             echo "Deploying all functions..."
             process_function "cmd/paper-to-kindle-checker/main.go" "$PAPER_TO_KINDLE_CHECKER" "$BUILD_ONLY"
             process_function "cmd/new-release-checker/main.go" "$NEW_RELEASE_CHECKER" "$BUILD_ONLY"
             process_function "cmd/sale-checker/main.go" "$SALE_CHECKER" "$BUILD_ONLY"
             process_function "cmd/release-notifier/main.go" "$RELEASE_NOTIFIER" "$BUILD_ONLY"
+            process_function "cmd/vacation-digest/main.go" "$VACATION_DIGEST" "$BUILD_ONLY"
+            process_function "cmd/author-changelog/main.go" "$AUTHOR_CHANGELOG" "$BUILD_ONLY"
+            process_function "cmd/approval-handler/main.go" "$APPROVAL_HANDLER" "$BUILD_ONLY"
+            process_function "cmd/scheduled-notifier/main.go" "$SCHEDULED_NOTIFIER" "$BUILD_ONLY"
             ;;
     esac
 }
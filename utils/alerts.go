@@ -0,0 +1,265 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/slack-go/slack"
+
+	"kindle_bot/render/textfmt"
+)
+
+const (
+	AcknowledgeAlertActionID = "critical-alert-ack"
+
+	circuitBreakerAlertPrefix = "circuit-breaker:"
+)
+
+var ErrAlertNotFound = errors.New("no open critical alert")
+
+type CriticalAlert struct {
+	ID            string    `json:"ID"`
+	Message       string    `json:"Message"`
+	Source        string    `json:"Source"`
+	RaisedAt      time.Time `json:"RaisedAt"`
+	LastAlertedAt time.Time `json:"LastAlertedAt"`
+	AlertCount    int       `json:"AlertCount"`
+}
+
+var criticalAlertConfig CriticalAlertConfig
+
+func RaiseCriticalAlert(cfg aws.Config, id string, err error) {
+	if EnvConfig.S3CriticalAlertsObjectKey == "" {
+		AlertToSlack(err, true)
+		return
+	}
+
+	now := time.Now()
+	var raised *CriticalAlert
+	updateErr := updateCriticalAlerts(cfg, func(alerts map[string]CriticalAlert) error {
+		alert, exists := alerts[id]
+		raised = nil
+		alert.Message = err.Error()
+		if !exists {
+			alert = CriticalAlert{ID: id, Message: err.Error(), Source: getFilename(), RaisedAt: now}
+			posted := alert
+			raised = &posted
+			alert.LastAlertedAt = now
+			alert.AlertCount++
+		}
+		alerts[id] = alert
+		return nil
+	})
+	if updateErr != nil {
+		log.Println("Error saving critical alerts:", updateErr)
+		AlertToSlack(err, true)
+		return
+	}
+
+	if raised != nil {
+		if postErr := postCriticalAlert(*raised); postErr != nil {
+			log.Println("Error posting critical alert:", postErr)
+		}
+	}
+}
+
+// RemindCriticalAlerts re-posts the alerts that have gone unacknowledged for
+// ReAlertHours. They are marked as re-alerted before posting so that runs
+// overlapping each other never post the same reminder twice.
+func RemindCriticalAlerts(cfg aws.Config, now time.Time) error {
+	if EnvConfig.S3CriticalAlertsObjectKey == "" || criticalAlertConfig.ReAlertHours <= 0 {
+		return nil
+	}
+
+	interval := time.Duration(criticalAlertConfig.ReAlertHours * float64(time.Hour))
+	alerts, err := FetchCriticalAlerts(cfg)
+	if err != nil {
+		return err
+	}
+	if !hasDueAlert(alerts, now, interval) {
+		return nil
+	}
+
+	var due []CriticalAlert
+	err = updateCriticalAlerts(cfg, func(alerts map[string]CriticalAlert) error {
+		due = nil
+		for id, alert := range alerts {
+			if now.Sub(alert.LastAlertedAt) < interval {
+				continue
+			}
+			due = append(due, alert)
+			alert.LastAlertedAt = now
+			alert.AlertCount++
+			alerts[id] = alert
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, alert := range due {
+		if err := postCriticalAlert(alert); err != nil {
+			return fmt.Errorf("failed to re-alert %s: %w", alert.ID, err)
+		}
+	}
+	return nil
+}
+
+func hasDueAlert(alerts map[string]CriticalAlert, now time.Time, interval time.Duration) bool {
+	for _, alert := range alerts {
+		if now.Sub(alert.LastAlertedAt) >= interval {
+			return true
+		}
+	}
+	return false
+}
+
+func AcknowledgeCriticalAlert(cfg aws.Config, id string) error {
+	return updateCriticalAlerts(cfg, func(alerts map[string]CriticalAlert) error {
+		if _, exists := alerts[id]; !exists {
+			return fmt.Errorf("%w: %s", ErrAlertNotFound, id)
+		}
+		delete(alerts, id)
+		return nil
+	})
+}
+
+func FetchCriticalAlerts(cfg aws.Config) (map[string]CriticalAlert, error) {
+	body, err := GetObject(cfg, EnvConfig.S3CriticalAlertsObjectKey)
+	if errors.Is(err, ErrObjectNotFound) {
+		return make(map[string]CriticalAlert), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch critical alerts: %w", err)
+	}
+	return decodeCriticalAlerts(body)
+}
+
+// updateCriticalAlerts applies update to the open alerts. Every checker
+// raises alerts while the Slack handler and admin acknowledge them, so the
+// write is conditional and retried on the latest alerts.
+func updateCriticalAlerts(cfg aws.Config, update func(map[string]CriticalAlert) error) error {
+	return UpdateObject(cfg, EnvConfig.S3CriticalAlertsObjectKey, func(body []byte) (string, error) {
+		alerts, err := decodeCriticalAlerts(body)
+		if err != nil {
+			return "", err
+		}
+		if err := update(alerts); err != nil {
+			return "", err
+		}
+
+		prettyJSON, err := json.MarshalIndent(alerts, "", "    ")
+		if err != nil {
+			return "", err
+		}
+		return string(prettyJSON), nil
+	})
+}
+
+func decodeCriticalAlerts(body []byte) (map[string]CriticalAlert, error) {
+	alerts := make(map[string]CriticalAlert)
+	if body == nil {
+		return alerts, nil
+	}
+	if err := json.Unmarshal(body, &alerts); err != nil {
+		return nil, err
+	}
+	return alerts, nil
+}
+
+func SortedCriticalAlerts(alerts map[string]CriticalAlert) []CriticalAlert {
+	var list []CriticalAlert
+	for _, alert := range alerts {
+		list = append(list, alert)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].RaisedAt.Before(list[j].RaisedAt)
+	})
+	return list
+}
+
+func postCriticalAlert(alert CriticalAlert) error {
+	text := textfmt.CriticalAlert(slackMentionUserID, alert.ID, alert.Source, alert.Message, alert.RaisedAt, alert.AlertCount)
+	if suppressOutbound("Slack "+EnvConfig.SlackErrorChannel, text) {
+		return nil
+	}
+
+	api := slack.New(EnvConfig.SlackBotToken)
+	_, _, err := api.PostMessage(
+		EnvConfig.SlackErrorChannel,
+		slack.MsgOptionText(text, false),
+		slack.MsgOptionBlocks(
+			slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, text, false, false), nil, nil),
+			slack.NewActionBlock("critical-alert",
+				slack.NewButtonBlockElement(AcknowledgeAlertActionID, alert.ID, slack.NewTextBlockObject(slack.PlainTextType, "確認済み", false, false)).WithStyle(slack.StylePrimary),
+			),
+		),
+	)
+	return err
+}
+
+type circuitBreakerState struct {
+	QuotaFailures int `json:"QuotaFailures"`
+}
+
+// recordCircuitBreaker counts consecutive runs of checker that ended on the
+// PA-API quota. Once CircuitBreakerRuns is reached the circuit is open and a
+// critical alert is raised until someone acknowledges it.
+func recordCircuitBreaker(checker string, processErr error) {
+	if criticalAlertConfig.CircuitBreakerRuns <= 0 || EnvConfig.S3CriticalAlertsObjectKey == "" {
+		return
+	}
+
+	cfg, err := InitAWSConfig()
+	if err != nil {
+		log.Println("Error loading AWS config for the circuit breaker:", err)
+		return
+	}
+
+	objectKey := CheckerObjectKey(EnvConfig.S3CriticalAlertsObjectKey, checker)
+	var state circuitBreakerState
+	body, err := GetObject(cfg, objectKey)
+	switch {
+	case errors.Is(err, ErrObjectNotFound):
+	case err != nil:
+		log.Println("Error fetching circuit breaker state:", err)
+		return
+	default:
+		if err := json.Unmarshal(body, &state); err != nil {
+			log.Println("Error decoding circuit breaker state:", err)
+			return
+		}
+	}
+
+	failures, open := nextQuotaFailures(state.QuotaFailures, processErr)
+	if open {
+		RaiseCriticalAlert(cfg, circuitBreakerAlertPrefix+checker,
+			fmt.Errorf("circuit breaker open: %d consecutive runs hit the PA-API quota, last error: %w", failures, processErr))
+	}
+	if failures == state.QuotaFailures {
+		return
+	}
+
+	prettyJSON, err := json.MarshalIndent(circuitBreakerState{QuotaFailures: failures}, "", "    ")
+	if err != nil {
+		log.Println("Error encoding circuit breaker state:", err)
+		return
+	}
+	if err := PutObject(cfg, string(prettyJSON), objectKey); err != nil {
+		log.Println("Error saving circuit breaker state:", err)
+	}
+}
+
+func nextQuotaFailures(failures int, processErr error) (int, bool) {
+	if !errors.Is(processErr, ErrAPIQuota) {
+		return 0, false
+	}
+	failures++
+	return failures, failures >= criticalAlertConfig.CircuitBreakerRuns
+}
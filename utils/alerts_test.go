@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestNextQuotaFailures(t *testing.T) {
+	criticalAlertConfig = CriticalAlertConfig{CircuitBreakerRuns: 3}
+	defer func() { criticalAlertConfig = CriticalAlertConfig{} }()
+
+	quotaErr := fmt.Errorf("%w: max retries reached", ErrAPIQuota)
+
+	tests := []struct {
+		name         string
+		failures     int
+		err          error
+		wantFailures int
+		wantOpen     bool
+	}{
+		{"success resets", 2, nil, 0, false},
+		{"other error resets", 2, errors.New("boom"), 0, false},
+		{"first quota failure", 0, quotaErr, 1, false},
+		{"threshold reached", 2, quotaErr, 3, true},
+		{"still open", 5, quotaErr, 6, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failures, open := nextQuotaFailures(tt.failures, tt.err)
+			if failures != tt.wantFailures || open != tt.wantOpen {
+				t.Errorf("nextQuotaFailures(%d, %v) = %d, %v, expected %d, %v", tt.failures, tt.err, failures, open, tt.wantFailures, tt.wantOpen)
+			}
+		})
+	}
+}
+
+func TestCriticalAlertsLifecycle(t *testing.T) {
+	EnvConfig.PAAPIMode = PAAPIModeMock
+	EnvConfig.S3CriticalAlertsObjectKey = "critical_alerts.json"
+	criticalAlertConfig = CriticalAlertConfig{ReAlertHours: 1}
+	defer func() {
+		EnvConfig.PAAPIMode = ""
+		EnvConfig.S3CriticalAlertsObjectKey = ""
+		criticalAlertConfig = CriticalAlertConfig{}
+	}()
+
+	datasetStore = memoryStore{}
+	defer func() { datasetStore = nil }()
+
+	alerts, err := FetchCriticalAlerts(aws.Config{})
+	if err != nil || len(alerts) != 0 {
+		t.Fatalf("FetchCriticalAlerts() = %v, %v, expected a missing object to have no alerts", alerts, err)
+	}
+
+	RaiseCriticalAlert(aws.Config{}, "shrink:books", errors.New("boom"))
+	if err := RemindCriticalAlerts(aws.Config{}, time.Now().Add(2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	alerts, err = FetchCriticalAlerts(aws.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alerts["shrink:books"].AlertCount != 2 {
+		t.Errorf("AlertCount = %d, expected the reminder to be counted", alerts["shrink:books"].AlertCount)
+	}
+
+	if err := AcknowledgeCriticalAlert(aws.Config{}, "shrink:books"); err != nil {
+		t.Fatal(err)
+	}
+	if err := AcknowledgeCriticalAlert(aws.Config{}, "shrink:books"); !errors.Is(err, ErrAlertNotFound) {
+		t.Errorf("AcknowledgeCriticalAlert() = %v, expected ErrAlertNotFound", err)
+	}
+}
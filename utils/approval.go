@@ -0,0 +1,353 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/slack-go/slack"
+
+	"kindle_bot/render/textfmt"
+)
+
+const (
+	ApprovalTargetDataset = "dataset"
+	ApprovalTargetGist    = "gist"
+
+	ApproveActionID = "approval-approve"
+	RejectActionID  = "approval-reject"
+)
+
+var ErrApprovalNotFound = errors.New("no pending approval")
+
+type PendingApproval struct {
+	ID           string    `json:"ID"`
+	Checker      string    `json:"Checker"`
+	Target       string    `json:"Target"`
+	ObjectKey    string    `json:"ObjectKey,omitempty"`
+	GistID       string    `json:"GistID,omitempty"`
+	GistFilename string    `json:"GistFilename,omitempty"`
+	BaseHash     string    `json:"BaseHash"`
+	Content      string    `json:"Content"`
+	Added        int       `json:"Added"`
+	Removed      int       `json:"Removed"`
+	CreatedAt    time.Time `json:"CreatedAt"`
+	SlackChannel string    `json:"SlackChannel,omitempty"`
+	SlackTS      string    `json:"SlackTS,omitempty"`
+}
+
+var approvalConfig ApprovalConfig
+
+func approvalConfigFor(configs *CheckerConfigs, checker string) ApprovalConfig {
+	switch checker {
+	case "sale-checker":
+		return configs.SaleChecker.Approval
+	case "new-release-checker":
+		return configs.NewReleaseChecker.Approval
+	case "paper-to-kindle-checker":
+		return configs.PaperToKindleChecker.Approval
+	case "admin":
+		return configs.DatasetApproval
+	default:
+		return ApprovalConfig{}
+	}
+}
+
+func (p PendingApproval) targetName() string {
+	if p.Target == ApprovalTargetGist {
+		return p.GistFilename
+	}
+	return p.ObjectKey
+}
+
+// HoldDatasetForApproval holds a dataset upload from admin push until it is
+// approved. The checkers' own dataset writes are run state and never held.
+func HoldDatasetForApproval(cfg aws.Config, objectKey string, before []byte, body string) (bool, error) {
+	return holdForApproval(cfg, PendingApproval{Target: ApprovalTargetDataset, ObjectKey: objectKey}, before, body)
+}
+
+func holdGistForApproval(cfg aws.Config, gistID, filename, before, markdown string) (bool, error) {
+	return holdForApproval(cfg, PendingApproval{Target: ApprovalTargetGist, GistID: gistID, GistFilename: filename}, []byte(before), markdown)
+}
+
+func holdForApproval(cfg aws.Config, pending PendingApproval, before []byte, content string) (bool, error) {
+	if !approvalConfig.Enabled || EnvConfig.S3PendingApprovalsObjectKey == "" {
+		return false, nil
+	}
+
+	removedLines, addedLines := diffLines(string(before), content)
+	if len(addedLines)+len(removedLines) == 0 || len(addedLines)+len(removedLines) < approvalConfig.MinChangedLines {
+		return false, nil
+	}
+
+	approvals, err := FetchPendingApprovals(cfg)
+	if err != nil {
+		return false, err
+	}
+
+	pending.Checker = checkerName()
+	pending.BaseHash = contentHash(before)
+	pending.Content = content
+	pending.Added = len(addedLines)
+	pending.Removed = len(removedLines)
+	pending.CreatedAt = time.Now()
+	pending.ID = fmt.Sprintf("%s:%s@%s", pending.Target, pending.targetName(), contentHash([]byte(content))[:8])
+
+	for id, existing := range approvals {
+		if existing.Target != pending.Target || existing.targetName() != pending.targetName() || id == pending.ID {
+			continue
+		}
+
+		// Gists are rendered again on every run, so the waiting preview is
+		// rewritten in place instead of being replaced by a new message. The
+		// content is only swapped once the approvers can see the new diff.
+		if pending.Target == ApprovalTargetGist {
+			if existing.Content == content {
+				return true, nil
+			}
+			existing.Content = content
+			existing.BaseHash = pending.BaseHash
+			existing.Added = pending.Added
+			existing.Removed = pending.Removed
+			if err := updateApprovalPreview(existing, removedLines, addedLines); err != nil {
+				return false, fmt.Errorf("failed to refresh approval preview: %w", err)
+			}
+			approvals[id] = existing
+			log.Printf("Refreshed change to %s awaiting approval (%s)", existing.targetName(), id)
+			return true, SavePendingApprovals(cfg, approvals)
+		}
+
+		delete(approvals, id)
+		updateApprovalMessage(existing, "⏭️ 新しい変更に置き換えられました")
+	}
+
+	if existing, exists := approvals[pending.ID]; exists {
+		log.Printf("Change to %s is already awaiting approval (%s)", pending.targetName(), existing.ID)
+		return true, nil
+	}
+
+	channel, ts, err := postApprovalPreview(pending, removedLines, addedLines)
+	if err != nil {
+		return false, fmt.Errorf("failed to post approval preview: %w", err)
+	}
+	pending.SlackChannel = channel
+	pending.SlackTS = ts
+	approvals[pending.ID] = pending
+
+	if err := SavePendingApprovals(cfg, approvals); err != nil {
+		return false, err
+	}
+
+	log.Printf("Holding change to %s for approval (%s, +%d -%d lines)", pending.targetName(), pending.ID, pending.Added, pending.Removed)
+	return true, nil
+}
+
+func ApplyApproval(cfg aws.Config, id, approver string) error {
+	approvals, err := FetchPendingApprovals(cfg)
+	if err != nil {
+		return err
+	}
+
+	pending, exists := approvals[id]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrApprovalNotFound, id)
+	}
+
+	stale, err := isApprovalStale(cfg, pending)
+	if err != nil {
+		return err
+	}
+	if stale {
+		delete(approvals, id)
+		if err := SavePendingApprovals(cfg, approvals); err != nil {
+			return err
+		}
+		updateApprovalMessage(pending, "⚠️ プレビュー後に内容が変更されたため破棄しました（次回の実行で新しいプレビューが作成されます）")
+		return fmt.Errorf("%s changed since the preview was created, discarded %s", pending.targetName(), id)
+	}
+
+	switch pending.Target {
+	case ApprovalTargetGist:
+		err = UpdateGist(pending.GistID, pending.GistFilename, pending.Content)
+	default:
+		err = PutObject(cfg, pending.Content, pending.ObjectKey)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to apply %s: %w", id, err)
+	}
+
+	delete(approvals, id)
+	if err := SavePendingApprovals(cfg, approvals); err != nil {
+		return err
+	}
+
+	updateApprovalMessage(pending, fmt.Sprintf("✅ %s が承認し、反映しました", approver))
+	return nil
+}
+
+func RejectApproval(cfg aws.Config, id, approver string) error {
+	approvals, err := FetchPendingApprovals(cfg)
+	if err != nil {
+		return err
+	}
+
+	pending, exists := approvals[id]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrApprovalNotFound, id)
+	}
+
+	delete(approvals, id)
+	if err := SavePendingApprovals(cfg, approvals); err != nil {
+		return err
+	}
+
+	updateApprovalMessage(pending, fmt.Sprintf("🚫 %s が却下しました", approver))
+	return nil
+}
+
+func FetchPendingApprovals(cfg aws.Config) (map[string]PendingApproval, error) {
+	approvals := make(map[string]PendingApproval)
+
+	body, err := GetObject(cfg, EnvConfig.S3PendingApprovalsObjectKey)
+	if errors.Is(err, ErrObjectNotFound) {
+		return approvals, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pending approvals: %w", err)
+	}
+
+	if err := json.Unmarshal(body, &approvals); err != nil {
+		return nil, err
+	}
+	return approvals, nil
+}
+
+func SavePendingApprovals(cfg aws.Config, approvals map[string]PendingApproval) error {
+	prettyJSON, err := json.MarshalIndent(approvals, "", "    ")
+	if err != nil {
+		return err
+	}
+	return PutObject(cfg, string(prettyJSON), EnvConfig.S3PendingApprovalsObjectKey)
+}
+
+func SortedPendingApprovals(approvals map[string]PendingApproval) []PendingApproval {
+	var list []PendingApproval
+	for _, pending := range approvals {
+		list = append(list, pending)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.Before(list[j].CreatedAt)
+	})
+	return list
+}
+
+// isApprovalStale reports whether the target changed since the preview was
+// created, so that approving never overwrites edits no approver has seen.
+func isApprovalStale(cfg aws.Config, pending PendingApproval) (bool, error) {
+	var current []byte
+	switch pending.Target {
+	case ApprovalTargetGist:
+		body, err := FetchGist(pending.GistID, pending.GistFilename)
+		if err != nil {
+			return false, fmt.Errorf("failed to fetch gist %s: %w", pending.GistFilename, err)
+		}
+		current = []byte(body)
+	default:
+		body, err := GetObject(cfg, pending.ObjectKey)
+		if err != nil && !errors.Is(err, ErrObjectNotFound) {
+			return false, fmt.Errorf("failed to fetch %s: %w", pending.ObjectKey, err)
+		}
+		current = body
+	}
+	return contentHash(current) != pending.BaseHash, nil
+}
+
+func postApprovalPreview(pending PendingApproval, removedLines, addedLines []string) (string, string, error) {
+	text := textfmt.ApprovalPreview(pending.Target, pending.targetName(), pending.Checker, pending.Added, pending.Removed, removedLines, addedLines)
+
+	if suppressOutbound("Slack "+EnvConfig.SlackNoticeChannel, text) {
+		return "", "", nil
+	}
+
+	api := slack.New(EnvConfig.SlackBotToken)
+	return api.PostMessage(EnvConfig.SlackNoticeChannel, approvalPreviewOptions(pending, text)...)
+}
+
+// updateApprovalPreview rewrites a waiting preview after its content changed.
+func updateApprovalPreview(pending PendingApproval, removedLines, addedLines []string) error {
+	text := textfmt.ApprovalPreview(pending.Target, pending.targetName(), pending.Checker, pending.Added, pending.Removed, removedLines, addedLines)
+	if pending.SlackTS == "" || suppressOutbound("Slack "+pending.SlackChannel, text) {
+		return nil
+	}
+
+	api := slack.New(EnvConfig.SlackBotToken)
+	_, _, _, err := api.UpdateMessage(pending.SlackChannel, pending.SlackTS, approvalPreviewOptions(pending, text)...)
+	return err
+}
+
+func approvalPreviewOptions(pending PendingApproval, text string) []slack.MsgOption {
+	return []slack.MsgOption{
+		slack.MsgOptionText(text, false),
+		slack.MsgOptionBlocks(
+			slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, text, false, false), nil, nil),
+			slack.NewActionBlock("approval",
+				slack.NewButtonBlockElement(ApproveActionID, pending.ID, slack.NewTextBlockObject(slack.PlainTextType, "承認", false, false)).WithStyle(slack.StylePrimary),
+				slack.NewButtonBlockElement(RejectActionID, pending.ID, slack.NewTextBlockObject(slack.PlainTextType, "却下", false, false)).WithStyle(slack.StyleDanger),
+			),
+		),
+	}
+}
+
+func updateApprovalMessage(pending PendingApproval, result string) {
+	if pending.SlackTS == "" {
+		return
+	}
+
+	text := textfmt.ApprovalResult(pending.Target, pending.targetName(), pending.Added, pending.Removed, result)
+	if suppressOutbound("Slack "+pending.SlackChannel, text) {
+		return
+	}
+
+	api := slack.New(EnvConfig.SlackBotToken)
+	if _, _, _, err := api.UpdateMessage(pending.SlackChannel, pending.SlackTS, slack.MsgOptionText(text, false),
+		slack.MsgOptionBlocks(slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, text, false, false), nil, nil))); err != nil {
+		log.Println("Error updating approval message:", err)
+	}
+}
+
+func diffLines(before, after string) (removed, added []string) {
+	remaining := make(map[string]int)
+	for _, line := range strings.Split(before, "\n") {
+		remaining[strings.TrimSpace(line)]++
+	}
+
+	for _, line := range strings.Split(after, "\n") {
+		key := strings.TrimSpace(line)
+		if remaining[key] > 0 {
+			remaining[key]--
+			continue
+		}
+		added = append(added, key)
+	}
+
+	for _, line := range strings.Split(before, "\n") {
+		key := strings.TrimSpace(line)
+		if remaining[key] > 0 {
+			remaining[key]--
+			removed = append(removed, key)
+		}
+	}
+	return removed, added
+}
+
+func contentHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
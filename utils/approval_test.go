@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after string
+		wantRemoved   []string
+		wantAdded     []string
+	}{
+		{"unchanged", "a\nb", "a\nb", nil, nil},
+		{"reordered", "a\nb", "b\na", nil, nil},
+		{"indentation ignored", "  a\nb", "a\n    b", nil, nil},
+		{"added and removed", "a\nb\nc", "a\nc\nd", []string{"b"}, []string{"d"}},
+		{"duplicates counted", "a\na", "a", []string{"a"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			removed, added := diffLines(tt.before, tt.after)
+			if !slices.Equal(removed, tt.wantRemoved) || !slices.Equal(added, tt.wantAdded) {
+				t.Errorf("diffLines() = -%v +%v, expected -%v +%v", removed, added, tt.wantRemoved, tt.wantAdded)
+			}
+		})
+	}
+}
+
+func TestHoldForApproval(t *testing.T) {
+	// Mock mode keeps the previews away from Slack.
+	EnvConfig.PAAPIMode = PAAPIModeMock
+	EnvConfig.S3PendingApprovalsObjectKey = "pending.json"
+	approvalConfig = ApprovalConfig{Enabled: true, MinChangedLines: 3}
+	store := memoryStore{"books.json": "a\nb"}
+	datasetStore = store
+	defer func() {
+		EnvConfig.PAAPIMode = ""
+		EnvConfig.S3PendingApprovalsObjectKey = ""
+		approvalConfig = ApprovalConfig{}
+		datasetStore = nil
+	}()
+
+	gist := PendingApproval{Target: ApprovalTargetGist, GistID: "gist", GistFilename: "books.md"}
+	dataset := PendingApproval{Target: ApprovalTargetDataset, ObjectKey: "books.json"}
+
+	tests := []struct {
+		name        string
+		pending     PendingApproval
+		before      string
+		content     string
+		wantHeld    bool
+		wantPending int
+		wantContent string
+	}{
+		{"small gist change is published", gist, "a\nb", "a\nc", false, 0, ""},
+		{"large gist change is held", gist, "a\nb", "c\nd", true, 1, "c\nd"},
+		{"next run refreshes the held gist", gist, "a\nb", "c\ne", true, 1, "c\ne"},
+		{"large dataset push is held", dataset, "a\nb", "x\ny", true, 2, "x\ny"},
+		{"newer dataset push replaces it", dataset, "a\nb", "x\nz", true, 2, "x\nz"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			held, err := holdForApproval(aws.Config{}, tt.pending, []byte(tt.before), tt.content)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if held != tt.wantHeld {
+				t.Errorf("holdForApproval() = %v, expected %v", held, tt.wantHeld)
+			}
+
+			approvals, err := FetchPendingApprovals(aws.Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(approvals) != tt.wantPending {
+				t.Errorf("%d pending approvals, expected %d", len(approvals), tt.wantPending)
+			}
+			for _, p := range approvals {
+				if p.Target == tt.pending.Target && p.Content != tt.wantContent {
+					t.Errorf("pending %s content = %q, expected %q", p.Target, p.Content, tt.wantContent)
+				}
+			}
+		})
+	}
+}
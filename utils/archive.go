@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"kindle_bot/render/textfmt"
+)
+
+const (
+	ArchiveKindBook   = "book"
+	ArchiveKindAuthor = "author"
+
+	watchUntilLayout = "2006-01-02"
+)
+
+type ArchiveEntry struct {
+	Kind       string          `json:"Kind"`
+	Name       string          `json:"Name"`
+	URL        string          `json:"URL,omitempty"`
+	WatchUntil string          `json:"WatchUntil"`
+	ArchivedAt time.Time       `json:"ArchivedAt"`
+	Entry      json.RawMessage `json:"Entry"`
+}
+
+// WatchExpired reports whether the JST date of now is past watchUntil.
+// The WatchUntil day itself is still watched.
+func WatchExpired(watchUntil string, now time.Time) bool {
+	if watchUntil == "" {
+		return false
+	}
+
+	jst := time.FixedZone("JST", 9*60*60)
+	until, err := time.ParseInLocation(watchUntilLayout, watchUntil, jst)
+	if err != nil {
+		log.Printf("Ignoring invalid WatchUntil %q: %v", watchUntil, err)
+		return false
+	}
+	return !now.In(jst).Before(until.AddDate(0, 0, 1))
+}
+
+func NewArchiveEntry(kind, name, url, watchUntil string, entry any, now time.Time) (ArchiveEntry, error) {
+	body, err := json.Marshal(entry)
+	if err != nil {
+		return ArchiveEntry{}, err
+	}
+	return ArchiveEntry{Kind: kind, Name: name, URL: url, WatchUntil: watchUntil, ArchivedAt: now, Entry: body}, nil
+}
+
+func ExpireWatchedBooks(cfg aws.Config, books []KindleBook, now time.Time) ([]KindleBook, error) {
+	remaining := make([]KindleBook, 0, len(books))
+	var expired []ArchiveEntry
+	for _, book := range books {
+		if !WatchExpired(book.WatchUntil, now) {
+			remaining = append(remaining, book)
+			continue
+		}
+
+		entry, err := NewArchiveEntry(ArchiveKindBook, book.Title, book.URL, book.WatchUntil, book, now)
+		if err != nil {
+			return nil, err
+		}
+		expired = append(expired, entry)
+	}
+
+	if err := ArchiveExpiredWatches(cfg, expired); err != nil {
+		return nil, err
+	}
+	return remaining, nil
+}
+
+func ArchiveExpiredWatches(cfg aws.Config, entries []ArchiveEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	// A removal held for approval is retried on the next run, so entries that
+	// are already archived are not notified again.
+	if EnvConfig.S3ArchiveObjectKey != "" {
+		archive, err := FetchArchive(cfg)
+		if err != nil {
+			return err
+		}
+
+		var added []ArchiveEntry
+		for _, e := range entries {
+			if !slices.ContainsFunc(archive, e.sameWatch) {
+				added = append(added, e)
+			}
+		}
+		if len(added) == 0 {
+			return nil
+		}
+		if err := SaveArchive(cfg, append(archive, added...)); err != nil {
+			return err
+		}
+		entries = added
+	}
+
+	for _, e := range entries {
+		log.Printf("Watch expired: archived %s %s (WatchUntil: %s)", e.Kind, e.Name, e.WatchUntil)
+		Notify(cfg, Notification{
+			Kind: NotificationWatchExpired,
+			Render: func(rc textfmt.RenderContext) string {
+				return textfmt.WatchExpired(rc, e.Kind == ArchiveKindAuthor, e.Name, e.URL, e.WatchUntil)
+			},
+		})
+	}
+	return nil
+}
+
+func (e ArchiveEntry) sameWatch(other ArchiveEntry) bool {
+	return e.Kind == other.Kind && e.Name == other.Name && e.WatchUntil == other.WatchUntil
+}
+
+func FetchArchive(cfg aws.Config) ([]ArchiveEntry, error) {
+	body, err := GetObject(cfg, EnvConfig.S3ArchiveObjectKey)
+	if errors.Is(err, ErrObjectNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch archive: %w", err)
+	}
+
+	var archive []ArchiveEntry
+	if err := json.Unmarshal(body, &archive); err != nil {
+		return nil, err
+	}
+	return archive, nil
+}
+
+func SaveArchive(cfg aws.Config, archive []ArchiveEntry) error {
+	prettyJSON, err := json.MarshalIndent(archive, "", "    ")
+	if err != nil {
+		return err
+	}
+	return PutObject(cfg, string(prettyJSON), EnvConfig.S3ArchiveObjectKey)
+}
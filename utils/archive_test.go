@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWatchExpired(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+
+	tests := []struct {
+		name       string
+		watchUntil string
+		now        time.Time
+		expected   bool
+	}{
+		{"No WatchUntil", "", time.Date(2030, 1, 1, 0, 0, 0, 0, jst), false},
+		{"Before the date", "2024-08-20", time.Date(2024, 8, 19, 23, 59, 0, 0, jst), false},
+		{"On the date", "2024-08-20", time.Date(2024, 8, 20, 23, 59, 0, 0, jst), false},
+		{"Day after the date", "2024-08-20", time.Date(2024, 8, 21, 0, 0, 0, 0, jst), true},
+		{"Day after the date in UTC", "2024-08-20", time.Date(2024, 8, 20, 15, 0, 0, 0, time.UTC), true},
+		{"Invalid date", "2024/08/20", time.Date(2030, 1, 1, 0, 0, 0, 0, jst), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := WatchExpired(tt.watchUntil, tt.now); got != tt.expected {
+				t.Errorf("WatchExpired(%q, %v) = %v, expected %v", tt.watchUntil, tt.now, got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+const (
+	AuditActionAdded   = "added"
+	AuditActionRemoved = "removed"
+)
+
+type AuthorsAuditLog struct {
+	Known              []string           `json:"Known"`
+	Entries            []AuthorAuditEntry `json:"Entries"`
+	LastPublishedMonth string             `json:"LastPublishedMonth,omitempty"`
+}
+
+type AuthorAuditEntry struct {
+	At     time.Time `json:"At"`
+	Action string    `json:"Action"`
+	Name   string    `json:"Name"`
+}
+
+func AuditAuthors(cfg aws.Config, names []string, now time.Time) error {
+	if EnvConfig.S3AuthorsAuditLogObjectKey == "" {
+		return nil
+	}
+
+	auditLog, err := FetchAuthorsAuditLog(cfg)
+	if err != nil {
+		return err
+	}
+
+	// Known must stay non-nil, even for an empty authors list, so the log
+	// is not initialised again on the next run.
+	current := append([]string{}, names...)
+	slices.Sort(current)
+	current = slices.Compact(current)
+
+	if auditLog.Known == nil {
+		auditLog.Known = current
+		log.Printf("Initialized authors audit log with %d authors", len(current))
+		return SaveAuthorsAuditLog(cfg, auditLog)
+	}
+
+	var entries []AuthorAuditEntry
+	for _, name := range current {
+		if _, found := slices.BinarySearch(auditLog.Known, name); !found {
+			entries = append(entries, AuthorAuditEntry{At: now, Action: AuditActionAdded, Name: name})
+		}
+	}
+	for _, name := range auditLog.Known {
+		if _, found := slices.BinarySearch(current, name); !found {
+			entries = append(entries, AuthorAuditEntry{At: now, Action: AuditActionRemoved, Name: name})
+		}
+	}
+
+	if len(entries) == 0 {
+		return nil
+	}
+
+	for _, e := range entries {
+		log.Printf("Authors audit: %s %s", e.Action, e.Name)
+	}
+	auditLog.Known = current
+	auditLog.Entries = append(auditLog.Entries, entries...)
+	return SaveAuthorsAuditLog(cfg, auditLog)
+}
+
+func FetchAuthorsAuditLog(cfg aws.Config) (*AuthorsAuditLog, error) {
+	body, err := GetObject(cfg, EnvConfig.S3AuthorsAuditLogObjectKey)
+	if errors.Is(err, ErrObjectNotFound) {
+		return &AuthorsAuditLog{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch authors audit log: %w", err)
+	}
+
+	return decodeAuthorsAuditLog(body)
+}
+
+func SaveAuthorsAuditLog(cfg aws.Config, auditLog *AuthorsAuditLog) error {
+	body, err := encodeAuthorsAuditLog(auditLog)
+	if err != nil {
+		return err
+	}
+	return PutObject(cfg, body, EnvConfig.S3AuthorsAuditLogObjectKey)
+}
+
+// MarkAuthorChangelogPublished records month as published on the latest audit
+// log, so entries audited while the changelog was being posted are kept.
+func MarkAuthorChangelogPublished(cfg aws.Config, month string) error {
+	err := UpdateObject(cfg, EnvConfig.S3AuthorsAuditLogObjectKey, func(body []byte) (string, error) {
+		auditLog, err := decodeAuthorsAuditLog(body)
+		if err != nil {
+			return "", err
+		}
+		auditLog.LastPublishedMonth = month
+		return encodeAuthorsAuditLog(auditLog)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update authors audit log: %w", err)
+	}
+	return nil
+}
+
+func decodeAuthorsAuditLog(body []byte) (*AuthorsAuditLog, error) {
+	var auditLog AuthorsAuditLog
+	if body == nil {
+		return &auditLog, nil
+	}
+	if err := json.Unmarshal(body, &auditLog); err != nil {
+		return nil, err
+	}
+	slices.Sort(auditLog.Known)
+	return &auditLog, nil
+}
+
+func encodeAuthorsAuditLog(auditLog *AuthorsAuditLog) (string, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "    ")
+	if err := enc.Encode(auditLog); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func NetAuthorChanges(entries []AuthorAuditEntry, from, to time.Time) (added, removed []string) {
+	net := make(map[string]int)
+	for _, e := range entries {
+		if e.At.Before(from) || !e.At.Before(to) {
+			continue
+		}
+		switch e.Action {
+		case AuditActionAdded:
+			net[e.Name]++
+		case AuditActionRemoved:
+			net[e.Name]--
+		}
+	}
+
+	for name, n := range net {
+		switch {
+		case n > 0:
+			added = append(added, name)
+		case n < 0:
+			removed = append(removed, name)
+		}
+	}
+	slices.Sort(added)
+	slices.Sort(removed)
+	return added, removed
+}
@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestNetAuthorChanges(t *testing.T) {
+	from := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+	entry := func(day int, action, name string) AuthorAuditEntry {
+		return AuthorAuditEntry{At: from.AddDate(0, 0, day), Action: action, Name: name}
+	}
+
+	tests := []struct {
+		name        string
+		entries     []AuthorAuditEntry
+		wantAdded   []string
+		wantRemoved []string
+	}{
+		{"no entries", nil, nil, nil},
+		{
+			"added and removed",
+			[]AuthorAuditEntry{entry(1, AuditActionAdded, "B"), entry(2, AuditActionAdded, "A"), entry(3, AuditActionRemoved, "C")},
+			[]string{"A", "B"}, []string{"C"},
+		},
+		{
+			"cancelled out within the month",
+			[]AuthorAuditEntry{entry(1, AuditActionAdded, "A"), entry(5, AuditActionRemoved, "A"), entry(6, AuditActionRemoved, "B"), entry(9, AuditActionAdded, "B")},
+			nil, nil,
+		},
+		{
+			"outside the month",
+			[]AuthorAuditEntry{entry(-1, AuditActionAdded, "A"), entry(31, AuditActionAdded, "B"), entry(0, AuditActionRemoved, "C")},
+			nil, []string{"C"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			added, removed := NetAuthorChanges(tt.entries, from, to)
+			if !slices.Equal(added, tt.wantAdded) || !slices.Equal(removed, tt.wantRemoved) {
+				t.Errorf("NetAuthorChanges() = %v, %v, expected %v, %v", added, removed, tt.wantAdded, tt.wantRemoved)
+			}
+		})
+	}
+}
+
+func TestMarkAuthorChangelogPublished(t *testing.T) {
+	EnvConfig.S3AuthorsAuditLogObjectKey = "authors_audit_log.json"
+	defer func() { EnvConfig.S3AuthorsAuditLogObjectKey = "" }()
+
+	store := memoryStore{}
+	datasetStore = store
+	defer func() { datasetStore = nil }()
+
+	if err := AuditAuthors(aws.Config{}, []string{"A"}, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := AuditAuthors(aws.Config{}, []string{"A", "B"}, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := MarkAuthorChangelogPublished(aws.Config{}, "2024-07"); err != nil {
+		t.Fatal(err)
+	}
+
+	auditLog, err := FetchAuthorsAuditLog(aws.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auditLog.LastPublishedMonth != "2024-07" || len(auditLog.Entries) != 1 || !slices.Equal(auditLog.Known, []string{"A", "B"}) {
+		t.Errorf("audit log = %+v, expected the month marked and the later audit kept", auditLog)
+	}
+}
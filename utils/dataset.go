@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+)
+
+const shrinkGuardMaxRatio = 0.1
+
+var (
+	deprecatedObjectKeys   map[string]string
+	warnedDeprecatedMu     sync.Mutex
+	warnedDeprecatedObject = make(map[string]bool)
+)
+
+func DatasetObjectKeys() []string {
+	keys := []string{
+		EnvConfig.S3UnprocessedObjectKey,
+		EnvConfig.S3PaperBooksObjectKey,
+		EnvConfig.S3AuthorsObjectKey,
+		EnvConfig.S3ExcludedTitleKeywordsObjectKey,
+		EnvConfig.S3NotifiedObjectKey,
+		EnvConfig.S3UpcomingObjectKey,
+		EnvConfig.S3AudibleNotifiedObjectKey,
+		EnvConfig.S3PrevIndexNewReleaseObjectKey,
+		EnvConfig.S3PrevIndexPaperToKindleObjectKey,
+		EnvConfig.S3PrevIndexSaleCheckerObjectKey,
+		EnvConfig.S3CheckerConfigObjectKey,
+		EnvConfig.S3VacationDigestObjectKey,
+		EnvConfig.S3CriticalAlertsObjectKey,
+		EnvConfig.S3AuthorsAuditLogObjectKey,
+		EnvConfig.S3PendingApprovalsObjectKey,
+		EnvConfig.S3ArchiveObjectKey,
+		EnvConfig.S3ScheduledNotificationsObjectKey,
+	}
+
+	var result []string
+	for _, key := range keys {
+		if key != "" {
+			result = append(result, key)
+		}
+	}
+	return result
+}
+
+func BookDatasetObjectKeys() []string {
+	return []string{
+		EnvConfig.S3UnprocessedObjectKey,
+		EnvConfig.S3PaperBooksObjectKey,
+		EnvConfig.S3NotifiedObjectKey,
+		EnvConfig.S3UpcomingObjectKey,
+		EnvConfig.S3AudibleNotifiedObjectKey,
+	}
+}
+
+// warnDeprecatedKey reports a read or write of an object key that
+// migrate-key has moved, once per process, so a checker still configured
+// with the old key does not silently diverge from the migrated data.
+func warnDeprecatedKey(objectKey string) {
+	newKey, ok := deprecatedObjectKeys[objectKey]
+	if !ok {
+		return
+	}
+
+	warnedDeprecatedMu.Lock()
+	warned := warnedDeprecatedObject[objectKey]
+	warnedDeprecatedObject[objectKey] = true
+	warnedDeprecatedMu.Unlock()
+
+	err := fmt.Errorf("%s is deprecated and was migrated to %s; update config.json / SSM to use the new key", objectKey, newKey)
+	log.Println("Warning:", err)
+	if !warned {
+		AlertToSlack(err, false)
+	}
+}
+
+func CheckShrink(objectKey string, before, after []byte) error {
+	var oldEntries, newEntries []json.RawMessage
+	if err := json.Unmarshal(before, &oldEntries); err != nil {
+		return nil
+	}
+	if err := json.Unmarshal(after, &newEntries); err != nil {
+		return fmt.Errorf("%w: %s: new content is not a JSON array: %w", ErrData, objectKey, err)
+	}
+
+	removed := len(oldEntries) - len(newEntries)
+	if removed <= 0 {
+		return nil
+	}
+
+	if float64(removed)/float64(len(oldEntries)) > shrinkGuardMaxRatio {
+		return fmt.Errorf("%w: %s: shrink guard triggered, entries would drop from %d to %d", ErrData, objectKey, len(oldEntries), len(newEntries))
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckShrink(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after string
+		wantErr       bool
+	}{
+		{"grown", `[1,2]`, `[1,2,3]`, false},
+		{"unchanged", `[1,2,3]`, `[3,2,1]`, false},
+		{"one of ten removed", `[1,2,3,4,5,6,7,8,9,10]`, `[1,2,3,4,5,6,7,8,9]`, false},
+		{"two of ten removed", `[1,2,3,4,5,6,7,8,9,10]`, `[1,2,3,4,5,6,7,8]`, true},
+		{"two of three removed", `[1,2,3]`, `[1]`, true},
+		{"emptied", `[1]`, `[]`, true},
+		{"remote not an array", `{}`, `[]`, false},
+		{"local not an array", `[1]`, `{}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckShrink("books.json", []byte(tt.before), []byte(tt.after))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckShrink() error = %v, expected error: %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrData) {
+				t.Errorf("CheckShrink() error = %v, expected ErrData", err)
+			}
+		})
+	}
+}
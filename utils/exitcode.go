@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+)
+
+const (
+	ExitOK             = 0
+	ExitFailure        = 1
+	ExitUsage          = 2
+	ExitConfigError    = 3
+	ExitDataError      = 4
+	ExitAPIQuota       = 5
+	ExitPartialSuccess = 6
+)
+
+var (
+	ErrUsage          = errors.New("invalid usage")
+	ErrConfig         = errors.New("configuration error")
+	ErrData           = errors.New("data error")
+	ErrAPIQuota       = errors.New("PA-API quota exhausted")
+	ErrPartialSuccess = errors.New("partial success")
+)
+
+var exitCodeDescriptions = []struct {
+	code        int
+	description string
+}{
+	{ExitOK, "success"},
+	{ExitFailure, "unclassified failure"},
+	{ExitUsage, "invalid command line usage"},
+	{ExitConfigError, "configuration error (config.json / SSM, checker configs, AWS or PA-API client setup)"},
+	{ExitDataError, "data error (missing or malformed dataset, shrink guard)"},
+	{ExitAPIQuota, "PA-API quota exhausted (still rate limited after all retries)"},
+	{ExitPartialSuccess, "partial success (some changes were applied before the failure)"},
+}
+
+// ExitCode maps an error returned by a command to its exit status. When an
+// error matches several classes, partial success wins so that wrappers know
+// some changes have already been applied.
+func ExitCode(err error) int {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, ErrPartialSuccess):
+		return ExitPartialSuccess
+	case errors.Is(err, ErrAPIQuota):
+		return ExitAPIQuota
+	case errors.Is(err, ErrUsage):
+		return ExitUsage
+	case errors.Is(err, ErrConfig):
+		return ExitConfigError
+	case errors.Is(err, ErrData), errors.Is(err, ErrObjectNotFound), errors.As(err, &syntaxErr), errors.As(err, &typeErr):
+		return ExitDataError
+	default:
+		return ExitFailure
+	}
+}
+
+// notifiedBeforeFailure marks a failure after notifications already went
+// out, e.g. a checker that notified and then failed to save, as partial
+// success so wrappers know a rerun may notify twice.
+func notifiedBeforeFailure(err error, notifications int) error {
+	if err == nil || notifications == 0 || errors.Is(err, ErrPartialSuccess) {
+		return err
+	}
+	return fmt.Errorf("%w: %d notification(s) were sent before the failure: %w", ErrPartialSuccess, notifications, err)
+}
+
+// Usage prints the command line flags and exit codes; each main sets it as
+// flag.Usage.
+func Usage() {
+	PrintUsage(flag.CommandLine)
+}
+
+func PrintUsage(fs *flag.FlagSet) {
+	fmt.Fprintf(fs.Output(), "Usage of %s:\n", fs.Name())
+	fs.PrintDefaults()
+	PrintExitCodes(fs.Output())
+}
+
+func PrintExitCodes(w io.Writer) {
+	fmt.Fprintln(w, "\nExit codes:")
+	for _, d := range exitCodeDescriptions {
+		fmt.Fprintf(w, "  %d  %s\n", d.code, d.description)
+	}
+}
@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestExitCode(t *testing.T) {
+	var syntaxErr error = json.Unmarshal([]byte("{"), &struct{}{})
+	_, dateErr := time.Parse("2006-01-02", "2024-13-01")
+
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{"Success", nil, ExitOK},
+		{"Unclassified", errors.New("boom"), ExitFailure},
+		{"Usage", fmt.Errorf("%w: unknown command: foo", ErrUsage), ExitUsage},
+		{"Invalid flag value", fmt.Errorf("%w: invalid -until date: %w", ErrUsage, dateErr), ExitUsage},
+		{"Wrapped config error", fmt.Errorf("failed to fetch checker configs: %w", fmt.Errorf("%w: %w", ErrConfig, ErrObjectNotFound)), ExitConfigError},
+		{"Missing dataset", fmt.Errorf("failed to fetch authors: %w", ErrObjectNotFound), ExitDataError},
+		{"Malformed dataset", fmt.Errorf("failed to fetch authors: %w", syntaxErr), ExitDataError},
+		{"Shrink guard", fmt.Errorf("%w: authors.json: shrink guard triggered", ErrData), ExitDataError},
+		{"API quota", fmt.Errorf("PA API processing failed: %w", ErrAPIQuota), ExitAPIQuota},
+		{"Partial success wins", fmt.Errorf("%w: uploaded 1 of 2 dataset(s): %w", ErrPartialSuccess, ErrData), ExitPartialSuccess},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.expected {
+				t.Errorf("ExitCode(%v) = %d, expected %d", tt.err, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNotifiedBeforeFailure(t *testing.T) {
+	saveErr := errors.New("failed to save notified books")
+	partialErr := fmt.Errorf("%w: uploaded 1 of 2 dataset(s)", ErrPartialSuccess)
+
+	tests := []struct {
+		name          string
+		err           error
+		notifications int
+		expected      int
+	}{
+		{"Success", nil, 3, ExitOK},
+		{"Failed before notifying", saveErr, 0, ExitFailure},
+		{"Notified, then failed to save", saveErr, 2, ExitPartialSuccess},
+		{"Notified, then hit the quota", fmt.Errorf("%w: max retries reached", ErrAPIQuota), 1, ExitPartialSuccess},
+		{"Already partial success", partialErr, 1, ExitPartialSuccess},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := notifiedBeforeFailure(tt.err, tt.notifications)
+			if got := ExitCode(err); got != tt.expected {
+				t.Errorf("ExitCode(notifiedBeforeFailure(%v, %d)) = %d, expected %d", tt.err, tt.notifications, got, tt.expected)
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("notifiedBeforeFailure() = %v, expected it to wrap %v", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"kindle_bot/render/textfmt"
+)
+
+const (
+	defaultRunHistoryMaxRecords          = 50
+	defaultRunHistoryGistIntervalMinutes = 60
+)
+
+type RunRecord struct {
+	Checker       string    `json:"Checker"`
+	StartedAt     time.Time `json:"StartedAt"`
+	DurationMs    int64     `json:"DurationMs"`
+	Item          string    `json:"Item,omitempty"`
+	Outcome       string    `json:"Outcome"`
+	Notifications int       `json:"Notifications"`
+	Error         string    `json:"Error,omitempty"`
+}
+
+type RunHistory struct {
+	GistUpdatedAt time.Time   `json:"GistUpdatedAt"`
+	Records       []RunRecord `json:"Records"`
+}
+
+const (
+	RunOutcomeSuccess = "success"
+	RunOutcomeFailure = "failure"
+)
+
+var (
+	runHistoryConfig RunHistoryConfig
+	currentRun       RunRecord
+)
+
+func SetRunItem(format string, args ...any) {
+	currentRun.Item = fmt.Sprintf(format, args...)
+}
+
+func countRunNotification() {
+	currentRun.Notifications++
+}
+
+func startRun(checker string, now time.Time) {
+	currentRun = RunRecord{Checker: checker, StartedAt: now}
+}
+
+func finishRun(processErr error) {
+	if EnvConfig.S3RunHistoryObjectKey == "" {
+		return
+	}
+	if currentRun.Item == "" && currentRun.Notifications == 0 && processErr == nil {
+		return
+	}
+
+	record := currentRun
+	record.DurationMs = time.Since(record.StartedAt).Milliseconds()
+	record.Outcome = RunOutcomeSuccess
+	if processErr != nil {
+		record.Outcome = RunOutcomeFailure
+		record.Error = processErr.Error()
+	}
+
+	cfg, err := InitAWSConfig()
+	if err != nil {
+		log.Println("Error loading AWS config for run history:", err)
+		return
+	}
+	if err := AppendRunRecord(cfg, record, time.Now()); err != nil {
+		log.Println("Error saving run history:", err)
+	}
+}
+
+// AppendRunRecord adds record to the run history of its checker. Each
+// checker keeps its own history object and gist file, so concurrently
+// running checkers never overwrite each other's records.
+func AppendRunRecord(cfg aws.Config, record RunRecord, now time.Time) error {
+	history, err := FetchRunHistory(cfg, record.Checker)
+	if err != nil {
+		return err
+	}
+
+	publish := runHistoryConfig.GistID != "" && isRunHistoryGistDue(history, record, now)
+	history.Records = trimRunHistory(append(history.Records, record))
+	if publish {
+		history.GistUpdatedAt = now
+	}
+
+	prettyJSON, err := json.MarshalIndent(history, "", "    ")
+	if err != nil {
+		return err
+	}
+	if err := PutObject(cfg, string(prettyJSON), CheckerObjectKey(EnvConfig.S3RunHistoryObjectKey, record.Checker)); err != nil {
+		return err
+	}
+
+	if !publish {
+		return nil
+	}
+	filename := CheckerObjectKey(runHistoryConfig.GistFilename, record.Checker)
+	return UpdateGist(runHistoryConfig.GistID, filename, textfmt.RunHistory(runHistoryRows(history.Records), now))
+}
+
+func FetchRunHistory(cfg aws.Config, checker string) (RunHistory, error) {
+	var history RunHistory
+	body, err := GetObject(cfg, CheckerObjectKey(EnvConfig.S3RunHistoryObjectKey, checker))
+	if errors.Is(err, ErrObjectNotFound) {
+		return history, nil
+	}
+	if err != nil {
+		return history, fmt.Errorf("failed to fetch run history: %w", err)
+	}
+
+	if err := json.Unmarshal(body, &history); err != nil {
+		return history, err
+	}
+	return history, nil
+}
+
+// isRunHistoryGistDue reports whether the gist should be rewritten for
+// record: when the outcome changed since the previous run, or when the gist
+// has not been updated for RunHistory.GistIntervalMinutes.
+func isRunHistoryGistDue(history RunHistory, record RunRecord, now time.Time) bool {
+	if len(history.Records) == 0 {
+		return true
+	}
+
+	last := history.Records[len(history.Records)-1]
+	if last.Outcome != record.Outcome || last.Error != record.Error {
+		return true
+	}
+
+	interval := runHistoryConfig.GistIntervalMinutes
+	if interval <= 0 {
+		interval = defaultRunHistoryGistIntervalMinutes
+	}
+	return now.Sub(history.GistUpdatedAt) >= time.Duration(interval)*time.Minute
+}
+
+func runHistoryRows(records []RunRecord) []textfmt.RunRow {
+	rows := make([]textfmt.RunRow, 0, len(records))
+	for _, r := range records {
+		rows = append(rows, textfmt.RunRow{
+			Checker:       r.Checker,
+			StartedAt:     r.StartedAt,
+			Duration:      time.Duration(r.DurationMs) * time.Millisecond,
+			Item:          r.Item,
+			Failed:        r.Outcome == RunOutcomeFailure,
+			Error:         r.Error,
+			Notifications: r.Notifications,
+		})
+	}
+	return rows
+}
+
+func trimRunHistory(records []RunRecord) []RunRecord {
+	maxRecords := runHistoryConfig.MaxRecordsPerChecker
+	if maxRecords <= 0 {
+		maxRecords = defaultRunHistoryMaxRecords
+	}
+
+	counts := make(map[string]int)
+	var kept []RunRecord
+	for i := len(records) - 1; i >= 0; i-- {
+		if counts[records[i].Checker] >= maxRecords {
+			continue
+		}
+		counts[records[i].Checker]++
+		kept = append(kept, records[i])
+	}
+
+	slices.Reverse(kept)
+	return kept
+}
+
+func checkerName() string {
+	return filepath.Base(filepath.Dir(getFilename()))
+}
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTrimRunHistory(t *testing.T) {
+	runHistoryConfig = RunHistoryConfig{MaxRecordsPerChecker: 2}
+	defer func() { runHistoryConfig = RunHistoryConfig{} }()
+
+	records := []RunRecord{
+		{Checker: "sale-checker", Item: "1"},
+		{Checker: "new-release-checker", Item: "2"},
+		{Checker: "sale-checker", Item: "3"},
+		{Checker: "sale-checker", Item: "4"},
+	}
+
+	var items []string
+	for _, r := range trimRunHistory(records) {
+		items = append(items, r.Item)
+	}
+	if got := strings.Join(items, ","); got != "2,3,4" {
+		t.Errorf("trimRunHistory() kept %s, expected 2,3,4", got)
+	}
+}
+
+func TestIsRunHistoryGistDue(t *testing.T) {
+	runHistoryConfig = RunHistoryConfig{GistIntervalMinutes: 30}
+	defer func() { runHistoryConfig = RunHistoryConfig{} }()
+
+	now := time.Date(2024, 8, 20, 9, 0, 0, 0, time.UTC)
+	success := RunRecord{Outcome: RunOutcomeSuccess}
+	failure := RunRecord{Outcome: RunOutcomeFailure, Error: "boom"}
+
+	tests := []struct {
+		name    string
+		history RunHistory
+		record  RunRecord
+		want    bool
+	}{
+		{"first run", RunHistory{}, success, true},
+		{"same outcome, recently updated", RunHistory{GistUpdatedAt: now.Add(-10 * time.Minute), Records: []RunRecord{success}}, success, false},
+		{"same outcome, interval passed", RunHistory{GistUpdatedAt: now.Add(-30 * time.Minute), Records: []RunRecord{success}}, success, true},
+		{"started failing", RunHistory{GistUpdatedAt: now, Records: []RunRecord{success}}, failure, true},
+		{"recovered", RunHistory{GistUpdatedAt: now, Records: []RunRecord{failure}}, success, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRunHistoryGistDue(tt.history, tt.record, now); got != tt.want {
+				t.Errorf("isRunHistoryGistDue() = %v, expected %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"time"
+
 	"github.com/goark/pa-api/entity"
 )
 
@@ -12,6 +14,7 @@ type Config struct {
 	S3ExcludedTitleKeywordsObjectKey  string `json:"S3ExcludedTitleKeywordsObjectKey"`
 	S3NotifiedObjectKey               string `json:"S3NotifiedObjectKey"`
 	S3UpcomingObjectKey               string `json:"S3UpcomingObjectKey"`
+	S3AudibleNotifiedObjectKey        string `json:"S3AudibleNotifiedObjectKey"`
 	S3PrevIndexNewReleaseObjectKey    string `json:"S3PrevIndexNewReleaseObjectKey"`
 	S3PrevIndexPaperToKindleObjectKey string `json:"S3PrevIndexPaperToKindleObjectKey"`
 	S3PrevIndexSaleCheckerObjectKey   string `json:"S3PrevIndexSaleCheckerObjectKey"`
@@ -26,49 +29,141 @@ type Config struct {
 	SlackBotToken                     string `json:"SlackBotToken"`
 	SlackNoticeChannel                string `json:"SlackNoticeChannel"`
 	SlackErrorChannel                 string `json:"SlackErrorChannel"`
+	SlackPriorityChannel              string `json:"SlackPriorityChannel"`
+	SlackSigningSecret                string `json:"SlackSigningSecret"`
 	GitHubToken                       string `json:"GitHubToken"`
 	S3CheckerConfigObjectKey          string `json:"S3CheckerConfigObjectKey"`
+	S3VacationDigestObjectKey         string `json:"S3VacationDigestObjectKey"`
+	S3WarmUpStateObjectKey            string `json:"S3WarmUpStateObjectKey"`
+	S3CriticalAlertsObjectKey         string `json:"S3CriticalAlertsObjectKey"`
+	S3RunHistoryObjectKey             string `json:"S3RunHistoryObjectKey"`
+	S3AuthorsAuditLogObjectKey        string `json:"S3AuthorsAuditLogObjectKey"`
+	S3PendingApprovalsObjectKey       string `json:"S3PendingApprovalsObjectKey"`
+	S3ArchiveObjectKey                string `json:"S3ArchiveObjectKey"`
+	S3ScheduledNotificationsObjectKey string `json:"S3ScheduledNotificationsObjectKey"`
+	DatasetStore                      string `json:"DatasetStore"`
+	GitDatasetURL                     string `json:"GitDatasetURL"`
+	GitDatasetBranch                  string `json:"GitDatasetBranch"`
+	GitDatasetPath                    string `json:"GitDatasetPath"`
+	GitDatasetToken                   string `json:"GitDatasetToken"`
+	PAAPIMode                         string `json:"PAAPIMode"`
+	PAAPIMockFixtureDir               string `json:"PAAPIMockFixtureDir"`
 }
 
 type CheckerConfigs struct {
 	ReportFailure        bool                       `json:"ReportFailure"`
+	TitleMaxLength       TitleMaxLengthConfig       `json:"TitleMaxLength"`
+	NotificationRouting  NotificationRoutingConfig  `json:"NotificationRouting"`
+	NotificationSchedule NotificationScheduleConfig `json:"NotificationSchedule"`
+	Vacation             VacationConfig             `json:"Vacation"`
+	WarmUp               WarmUpConfig               `json:"WarmUp"`
+	CriticalAlerts       CriticalAlertConfig        `json:"CriticalAlerts"`
+	RunHistory           RunHistoryConfig           `json:"RunHistory"`
+	AudibleBrowseNodeID  string                     `json:"AudibleBrowseNodeID"`
+	DeprecatedObjectKeys map[string]string          `json:"DeprecatedObjectKeys,omitempty"`
+	DatasetApproval      ApprovalConfig             `json:"DatasetApproval"`
+	AuthorChangelog      AuthorChangelogConfig      `json:"AuthorChangelog"`
 	SaleChecker          SaleCheckerConfig          `json:"SaleChecker"`
 	NewReleaseChecker    NewReleaseCheckerConfig    `json:"NewReleaseChecker"`
 	PaperToKindleChecker PaperToKindleCheckerConfig `json:"PaperToKindleChecker"`
 }
 
+type TitleMaxLengthConfig struct {
+	Slack    int `json:"Slack"`
+	Mastodon int `json:"Mastodon"`
+}
+
+type NotificationRoutingConfig struct {
+	PriorityReleaseWithinDays int      `json:"PriorityReleaseWithinDays"`
+	PriorityKinds             []string `json:"PriorityKinds"`
+	PriorityMention           bool     `json:"PriorityMention"`
+}
+
+type NotificationScheduleConfig struct {
+	SendTime        string   `json:"SendTime"`
+	Kinds           []string `json:"Kinds"`
+	UntilReleaseDay bool     `json:"UntilReleaseDay"`
+}
+
+type VacationConfig struct {
+	Enabled bool      `json:"Enabled"`
+	Until   time.Time `json:"Until"`
+}
+
+type WarmUpConfig struct {
+	DurationMinutes        int     `json:"DurationMinutes"`
+	IdleHours              float64 `json:"IdleHours"`
+	SegmentSize            int     `json:"SegmentSize"`
+	RequestIntervalSeconds int     `json:"RequestIntervalSeconds"`
+	RetryWaitMultiplier    int     `json:"RetryWaitMultiplier"`
+	MaxThrottleRate        float64 `json:"MaxThrottleRate"`
+}
+
+type CriticalAlertConfig struct {
+	ReAlertHours       float64 `json:"ReAlertHours"`
+	CircuitBreakerRuns int     `json:"CircuitBreakerRuns"`
+}
+
+type RunHistoryConfig struct {
+	GistID               string `json:"GistID"`
+	GistFilename         string `json:"GistFilename"`
+	GistIntervalMinutes  int    `json:"GistIntervalMinutes"`
+	MaxRecordsPerChecker int    `json:"MaxRecordsPerChecker"`
+}
+
+type AuthorChangelogConfig struct {
+	Enabled      bool   `json:"Enabled"`
+	Mastodon     bool   `json:"Mastodon"`
+	GistID       string `json:"GistID"`
+	GistFilename string `json:"GistFilename"`
+}
+
+type ApprovalConfig struct {
+	Enabled         bool `json:"Enabled"`
+	MinChangedLines int  `json:"MinChangedLines"`
+}
+
 type SaleCheckerConfig struct {
-	Enabled                     bool   `json:"Enabled"`
-	GistID                      string `json:"GistID"`
-	GistFilename                string `json:"GistFilename"`
-	ExecutionIntervalMinutes    int    `json:"ExecutionIntervalMinutes"`
-	GetItemsPaapiRetryCount     int    `json:"GetItemsPaapiRetryCount"`
-	GetItemsInitialRetrySeconds int    `json:"GetItemsInitialRetrySeconds"`
-	SaleThreshold               int    `json:"SaleThreshold"`
-	PointPercent                int    `json:"PointPercent"`
-	PriceChangeAmount           int    `json:"PriceChangeAmount"`
+	Enabled                     bool           `json:"Enabled"`
+	GistID                      string         `json:"GistID"`
+	GistFilename                string         `json:"GistFilename"`
+	ExecutionIntervalMinutes    int            `json:"ExecutionIntervalMinutes"`
+	GetItemsPaapiRetryCount     int            `json:"GetItemsPaapiRetryCount"`
+	GetItemsInitialRetrySeconds int            `json:"GetItemsInitialRetrySeconds"`
+	SaleThreshold               int            `json:"SaleThreshold"`
+	PointPercent                int            `json:"PointPercent"`
+	PriceChangeAmount           int            `json:"PriceChangeAmount"`
+	SnoozeUntil                 time.Time      `json:"SnoozeUntil"`
+	SnoozeMuteNotifications     bool           `json:"SnoozeMuteNotifications"`
+	SnoozeSaleThreshold         int            `json:"SnoozeSaleThreshold"`
+	SnoozePointPercent          int            `json:"SnoozePointPercent"`
+	MaxPriceCap                 int            `json:"MaxPriceCap"`
+	MaxPriceCapExclude          bool           `json:"MaxPriceCapExclude"`
+	Approval                    ApprovalConfig `json:"Approval"`
 }
 
 type NewReleaseCheckerConfig struct {
-	Enabled                        bool    `json:"Enabled"`
-	GistID                         string  `json:"GistID"`
-	GistFilename                   string  `json:"GistFilename"`
-	CycleDays                      float64 `json:"CycleDays"`
-	SearchItemsPaapiRetryCount     int     `json:"SearchItemsPaapiRetryCount"`
-	SearchItemsInitialRetrySeconds int     `json:"SearchItemsInitialRetrySeconds"`
-	GetItemsPaapiRetryCount        int     `json:"GetItemsPaapiRetryCount"`
-	GetItemsInitialRetrySeconds    int     `json:"GetItemsInitialRetrySeconds"`
+	Enabled                        bool           `json:"Enabled"`
+	GistID                         string         `json:"GistID"`
+	GistFilename                   string         `json:"GistFilename"`
+	CycleDays                      float64        `json:"CycleDays"`
+	SearchItemsPaapiRetryCount     int            `json:"SearchItemsPaapiRetryCount"`
+	SearchItemsInitialRetrySeconds int            `json:"SearchItemsInitialRetrySeconds"`
+	GetItemsPaapiRetryCount        int            `json:"GetItemsPaapiRetryCount"`
+	GetItemsInitialRetrySeconds    int            `json:"GetItemsInitialRetrySeconds"`
+	Approval                       ApprovalConfig `json:"Approval"`
 }
 
 type PaperToKindleCheckerConfig struct {
-	Enabled                        bool    `json:"Enabled"`
-	GistID                         string  `json:"GistID"`
-	GistFilename                   string  `json:"GistFilename"`
-	CycleDays                      float64 `json:"CycleDays"`
-	SearchItemsPaapiRetryCount     int     `json:"SearchItemsPaapiRetryCount"`
-	SearchItemsInitialRetrySeconds int     `json:"SearchItemsInitialRetrySeconds"`
-	GetItemsPaapiRetryCount        int     `json:"GetItemsPaapiRetryCount"`
-	GetItemsInitialRetrySeconds    int     `json:"GetItemsInitialRetrySeconds"`
+	Enabled                        bool           `json:"Enabled"`
+	GistID                         string         `json:"GistID"`
+	GistFilename                   string         `json:"GistFilename"`
+	CycleDays                      float64        `json:"CycleDays"`
+	SearchItemsPaapiRetryCount     int            `json:"SearchItemsPaapiRetryCount"`
+	SearchItemsInitialRetrySeconds int            `json:"SearchItemsInitialRetrySeconds"`
+	GetItemsPaapiRetryCount        int            `json:"GetItemsPaapiRetryCount"`
+	GetItemsInitialRetrySeconds    int            `json:"GetItemsInitialRetrySeconds"`
+	Approval                       ApprovalConfig `json:"Approval"`
 }
 
 type KindleBook struct {
@@ -78,6 +173,30 @@ type KindleBook struct {
 	CurrentPrice float64     `json:"CurrentPrice"`
 	MaxPrice     float64     `json:"MaxPrice"`
 	URL          string      `json:"URL"`
+	Source       string      `json:"Source,omitempty"`
+	AddedAt      string      `json:"AddedAt,omitempty"`
+	OverPriceCap bool        `json:"OverPriceCap,omitempty"`
+	OfferCount   int         `json:"OfferCount,omitempty"`
+	TrackAudible bool        `json:"TrackAudible,omitempty"`
+	WatchUntil   string      `json:"WatchUntil,omitempty"`
+}
+
+type Author struct {
+	Name               string    `json:"Name"`
+	URL                string    `json:"URL"`
+	LatestReleaseDate  time.Time `json:"LatestReleaseDate"`
+	LatestReleaseTitle string    `json:"LatestReleaseTitle"`
+	LatestReleaseURL   string    `json:"LatestReleaseURL"`
+	TrackAudible       bool      `json:"TrackAudible"`
+	ExcludeKeywords    []string  `json:"ExcludeKeywords,omitempty"`
+	RequireKeywords    []string  `json:"RequireKeywords,omitempty"`
+	WatchUntil         string    `json:"WatchUntil,omitempty"`
+}
+
+type DigestEntry struct {
+	Kind      string    `json:"Kind"`
+	Message   string    `json:"Message"`
+	CreatedAt time.Time `json:"CreatedAt"`
 }
 
 type GistFileContent struct {
@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"kindle_bot/render/textfmt"
+)
+
+type NotificationKind string
+
+const (
+	NotificationSale          NotificationKind = "sale"
+	NotificationPriceChange   NotificationKind = "price-change"
+	NotificationNewRelease    NotificationKind = "new-release"
+	NotificationPaperToKindle NotificationKind = "paper-to-kindle"
+	NotificationReleaseToday  NotificationKind = "release-today"
+	NotificationAudible       NotificationKind = "audible"
+	NotificationWatchExpired  NotificationKind = "watch-expired"
+	NotificationAuthorChanges NotificationKind = "author-changelog"
+)
+
+var defaultPriorityKinds = []string{string(NotificationSale), string(NotificationPriceChange)}
+
+type Notification struct {
+	Kind        NotificationKind
+	ReleaseDate time.Time
+	Public      bool
+	Render      textfmt.MessageRenderer
+}
+
+// Notify sends n to Slack right away. Its Mastodon post is held until the
+// NotificationSchedule send time when its kind is scheduled.
+func Notify(cfg aws.Config, n Notification) {
+	now := time.Now()
+	if sendAt := scheduledSendAt(n, now); !sendAt.IsZero() && !IsOnVacation(now) {
+		if err := schedulePublicPost(cfg, n, now, sendAt); err != nil {
+			AlertToSlack(fmt.Errorf("failed to schedule %s Mastodon post, posting it now: %v", n.Kind, err), false)
+		} else {
+			n.Public = false
+		}
+	}
+
+	deliver(cfg, n, n.Render(textfmt.RenderContext{Now: now, TitleMaxLength: titleMaxLength.Slack}), func() string {
+		return n.Render(textfmt.RenderContext{Now: now, TitleMaxLength: titleMaxLength.Mastodon})
+	}, now)
+}
+
+func deliver(cfg aws.Config, n Notification, message string, publicMessage func() string, now time.Time) {
+	log.Println(message)
+	countRunNotification()
+
+	priority := isPriority(n, now)
+	if IsOnVacation(now) {
+		if !priority {
+			log.Printf("Vacation mode: holding %s notification for the catch-up digest", n.Kind)
+			err := appendDigestEntry(cfg, DigestEntry{Kind: string(n.Kind), Message: message, CreatedAt: now})
+			if err == nil {
+				return
+			}
+			AlertToSlack(fmt.Errorf("failed to save vacation digest entry, sending it now: %v", err), false)
+		}
+	} else if n.Public {
+		if _, err := TootMastodon(publicMessage()); err != nil {
+			AlertToSlack(fmt.Errorf("failed to post to Mastodon: %v", err), false)
+		}
+	}
+
+	channel := EnvConfig.SlackNoticeChannel
+	if priority {
+		log.Printf("Routing %s notification to the priority channel", n.Kind)
+		if EnvConfig.SlackPriorityChannel != "" {
+			channel = EnvConfig.SlackPriorityChannel
+		}
+		if routing.PriorityMention {
+			message = fmt.Sprintf("<@%s> %s", slackMentionUserID, message)
+		}
+	}
+
+	if err := PostToSlack(message, channel); err != nil {
+		AlertToSlack(fmt.Errorf("failed to post to Slack: %v", err), false)
+	}
+}
+
+func isPriority(n Notification, now time.Time) bool {
+	if routing.PriorityReleaseWithinDays <= 0 || n.ReleaseDate.IsZero() {
+		return false
+	}
+
+	kinds := routing.PriorityKinds
+	if len(kinds) == 0 {
+		kinds = defaultPriorityKinds
+	}
+	if !slices.Contains(kinds, string(n.Kind)) {
+		return false
+	}
+
+	days := textfmt.RenderContext{Now: now}.DaysUntil(n.ReleaseDate)
+	return days >= 0 && days <= routing.PriorityReleaseWithinDays
+}
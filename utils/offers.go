@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"strings"
+
+	"github.com/goark/pa-api/entity"
+)
+
+const amazonMerchantPrefix = "Amazon"
+
+type Offer struct {
+	Price         float64
+	LoyaltyPoints int
+	Merchant      string
+	Count         int
+}
+
+// SelectOffer picks the Amazon retail listing when PA-API returns several,
+// falling back to the buy box winner and then to the first priced listing.
+func SelectOffer(item entity.Item) (Offer, bool) {
+	if item.Offers == nil || item.Offers.Listings == nil {
+		return Offer{}, false
+	}
+	listings := *item.Offers.Listings
+
+	selected := -1
+	for i, listing := range listings {
+		if listing.Price == nil || listing.Price.GenPriceInfo == nil {
+			continue
+		}
+		if listing.MerchantInfo != nil && strings.HasPrefix(listing.MerchantInfo.Name, amazonMerchantPrefix) {
+			selected = i
+			break
+		}
+		if selected == -1 || (listing.IsBuyboxWinner && !listings[selected].IsBuyboxWinner) {
+			selected = i
+		}
+	}
+	if selected == -1 {
+		return Offer{Count: len(listings)}, false
+	}
+
+	listing := listings[selected]
+	offer := Offer{
+		Price: listing.Price.Amount,
+		Count: len(listings),
+	}
+	if listing.LoyaltyPoints != nil {
+		offer.LoyaltyPoints = listing.LoyaltyPoints.Points
+	}
+	if listing.MerchantInfo != nil {
+		offer.Merchant = listing.MerchantInfo.Name
+	}
+	return offer, true
+}
@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/goark/pa-api/entity"
+)
+
+func TestSelectOffer(t *testing.T) {
+	tests := []struct {
+		name     string
+		offers   string
+		expected Offer
+		ok       bool
+	}{
+		{
+			name:   "No offers",
+			offers: `null`,
+			ok:     false,
+		},
+		{
+			name:     "Single listing",
+			offers:   `{"Listings": [{"Price": {"Amount": 660}, "LoyaltyPoints": {"Points": 7}}]}`,
+			expected: Offer{Price: 660, LoyaltyPoints: 7, Count: 1},
+			ok:       true,
+		},
+		{
+			name: "Amazon retail listing wins regardless of order",
+			offers: `{"Listings": [
+				{"Price": {"Amount": 9800}, "IsBuyboxWinner": true, "MerchantInfo": {"Name": "Marketplace Seller"}},
+				{"Price": {"Amount": 660}, "LoyaltyPoints": {"Points": 7}, "MerchantInfo": {"Name": "Amazon.co.jp"}}
+			]}`,
+			expected: Offer{Price: 660, LoyaltyPoints: 7, Merchant: "Amazon.co.jp", Count: 2},
+			ok:       true,
+		},
+		{
+			name: "Buy box winner without an Amazon listing",
+			offers: `{"Listings": [
+				{"Price": {"Amount": 9800}},
+				{"Price": {"Amount": 700}, "IsBuyboxWinner": true}
+			]}`,
+			expected: Offer{Price: 700, Count: 2},
+			ok:       true,
+		},
+		{
+			name: "Skip listings without a price",
+			offers: `{"Listings": [
+				{"MerchantInfo": {"Name": "Amazon.co.jp"}},
+				{"Price": {"Amount": 800}}
+			]}`,
+			expected: Offer{Price: 800, Count: 2},
+			ok:       true,
+		},
+		{
+			name:     "No priced listings",
+			offers:   `{"Listings": [{"MerchantInfo": {"Name": "Amazon.co.jp"}}]}`,
+			expected: Offer{Count: 1},
+			ok:       false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var item entity.Item
+			if err := json.Unmarshal([]byte(`{"ASIN": "B000000001", "Offers": `+tt.offers+`}`), &item); err != nil {
+				t.Fatalf("invalid item JSON: %v", err)
+			}
+
+			offer, ok := SelectOffer(item)
+			if ok != tt.ok || offer != tt.expected {
+				t.Errorf("SelectOffer() = %+v, %v, expected %+v, %v", offer, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	PAAPIModeProduction = "production"
+	PAAPIModeMock       = "mock"
+)
+
+type mockPAAPITransport struct {
+	fixtureDir string
+}
+
+type mockPAAPIRequest struct {
+	ItemIds      []string `json:"ItemIds"`
+	Keywords     string   `json:"Keywords"`
+	Author       string   `json:"Author"`
+	Title        string   `json:"Title"`
+	BrowseNodeID string   `json:"BrowseNodeId"`
+}
+
+var audibleBrowseNodeID string
+
+func IsPAAPIMock() bool {
+	return EnvConfig.PAAPIMode == PAAPIModeMock
+}
+
+// suppressOutbound logs a message instead of sending it in mock mode, so
+// fixture items never reach Slack, Mastodon or the gists.
+func suppressOutbound(target, message string) bool {
+	if !IsPAAPIMock() {
+		return false
+	}
+	log.Printf("PA-API mock mode: not sending to %s:\n%s", target, message)
+	return true
+}
+
+// mockDatasetStore reads the configured store but keeps writes in memory,
+// so mock runs never put fixture items into the live datasets.
+type mockDatasetStore struct {
+	base   DatasetStore
+	writes map[string]string
+}
+
+func (m *mockDatasetStore) Get(objectKey string) ([]byte, error) {
+	if body, ok := m.writes[objectKey]; ok {
+		return []byte(body), nil
+	}
+	return m.base.Get(objectKey)
+}
+
+func (m *mockDatasetStore) Put(objectKey, body string) error {
+	log.Printf("PA-API mock mode: not saving %s", objectKey)
+	m.writes[objectKey] = body
+	return nil
+}
+
+func newPAAPIHTTPClient() (*http.Client, error) {
+	switch EnvConfig.PAAPIMode {
+	case "", PAAPIModeProduction:
+		return &http.Client{}, nil
+	case PAAPIModeMock:
+		log.Println("PA-API mock mode enabled, no requests are sent to Amazon")
+		return &http.Client{Transport: mockPAAPITransport{fixtureDir: EnvConfig.PAAPIMockFixtureDir}}, nil
+	default:
+		return nil, fmt.Errorf("unknown PA-API mode: %s", EnvConfig.PAAPIMode)
+	}
+}
+
+func (t mockPAAPITransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var operation string
+	switch path := strings.ToLower(req.URL.Path); {
+	case strings.HasSuffix(path, "/getitems"):
+		operation = "GetItems"
+	case strings.HasSuffix(path, "/searchitems"):
+		operation = "SearchItems"
+	default:
+		return mockPAAPIResponse(req, http.StatusNotFound, []byte(`{"Errors":[{"Code":"UnknownOperation","Message":"unsupported operation in mock mode"}]}`)), nil
+	}
+
+	if t.fixtureDir != "" {
+		body, err := os.ReadFile(filepath.Join(t.fixtureDir, operation+".json"))
+		if err == nil {
+			return mockPAAPIResponse(req, http.StatusOK, body), nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	var payload mockPAAPIRequest
+	if req.Body != nil {
+		data, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return nil, fmt.Errorf("failed to decode mock PA-API request: %w", err)
+		}
+	}
+
+	var result map[string]any
+	if operation == "GetItems" {
+		var items []map[string]any
+		for _, asin := range payload.ItemIds {
+			items = append(items, mockPAAPIItem(asin, "モック書籍 "+asin, "モック作者", 7))
+		}
+		result = map[string]any{"ItemsResult": map[string]any{"Items": items}}
+	} else {
+		title := strings.TrimSpace(payload.Title + " " + payload.Keywords)
+		if title == "" {
+			title = "モック新刊"
+		}
+		author := payload.Author
+		if author == "" {
+			author = "モック作者"
+		}
+		item := mockPAAPIItem("BMOCK00001", title+" 1", author, 14)
+		if payload.BrowseNodeID != "" && payload.BrowseNodeID == audibleBrowseNodeID {
+			item = mockPAAPIItem("BMOCKAUD01", title+" 1", author, 14)
+			item["ItemInfo"].(map[string]any)["Classifications"] = map[string]any{"Binding": map[string]any{"DisplayValue": BindingAudible}}
+			delete(item, "Offers")
+		}
+		items := []map[string]any{item}
+		result = map[string]any{"SearchResult": map[string]any{"Items": items, "TotalResultCount": len(items)}}
+	}
+
+	body, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return mockPAAPIResponse(req, http.StatusOK, body), nil
+}
+
+func mockPAAPIItem(asin, title, author string, releaseInDays int) map[string]any {
+	binding := BindingKindle
+	if !strings.HasPrefix(asin, "B") {
+		binding = "コミック"
+	}
+	releaseDate := time.Now().AddDate(0, 0, releaseInDays).UTC().Format("2006-01-02T00:00:00Z")
+
+	return map[string]any{
+		"ASIN":          asin,
+		"DetailPageURL": "https://www.amazon.co.jp/dp/" + asin,
+		"ItemInfo": map[string]any{
+			"Title":           map[string]any{"DisplayValue": title},
+			"Classifications": map[string]any{"Binding": map[string]any{"DisplayValue": binding}},
+			"ProductInfo":     map[string]any{"ReleaseDate": map[string]any{"DisplayValue": releaseDate}},
+			"ByLineInfo": map[string]any{"Contributors": []map[string]any{
+				{"Name": author, "Role": "著", "RoleType": "author", "Locale": "ja_JP"},
+			}},
+		},
+		"Offers": map[string]any{"Listings": []map[string]any{{
+			"Price":         map[string]any{"Amount": 1000, "Currency": "JPY", "DisplayAmount": "￥1,000"},
+			"LoyaltyPoints": map[string]any{"Points": 10},
+			"MerchantInfo":  map[string]any{"Name": "Amazon.co.jp"},
+		}}},
+	}
+}
+
+func mockPAAPIResponse(req *http.Request, status int, body []byte) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
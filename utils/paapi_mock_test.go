@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"fmt"
+	"testing"
+)
+
+type memoryStore map[string]string
+
+func (m memoryStore) Get(objectKey string) ([]byte, error) {
+	body, ok := m[objectKey]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", objectKey, ErrObjectNotFound)
+	}
+	return []byte(body), nil
+}
+
+func (m memoryStore) Put(objectKey, body string) error {
+	m[objectKey] = body
+	return nil
+}
+
+func TestMockModeKeepsLiveDataUntouched(t *testing.T) {
+	EnvConfig.PAAPIMode = PAAPIModeMock
+	defer func() { EnvConfig.PAAPIMode = "" }()
+
+	live := memoryStore{"notified.json": `[]`}
+	store := &mockDatasetStore{base: live, writes: map[string]string{}}
+
+	if err := store.Put("notified.json", `[{"ASIN":"BMOCK00001"}]`); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := store.Get("notified.json"); string(got) != `[{"ASIN":"BMOCK00001"}]` {
+		t.Errorf("Get() = %s, expected the write to be visible within the run", got)
+	}
+	if live["notified.json"] != `[]` {
+		t.Errorf("live dataset = %s, expected it unchanged", live["notified.json"])
+	}
+
+	if !suppressOutbound("Slack", "📚 セール情報: モック書籍 BMOCK00001") {
+		t.Error("suppressOutbound() = false in mock mode")
+	}
+	if err := PostToSlack("📚 セール情報: モック書籍 BMOCK00001", "#notice"); err != nil {
+		t.Errorf("PostToSlack() error = %v", err)
+	}
+
+	EnvConfig.PAAPIMode = PAAPIModeProduction
+	if suppressOutbound("Slack", "message") {
+		t.Error("suppressOutbound() = true in production mode")
+	}
+}
@@ -0,0 +1,158 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"kindle_bot/render/textfmt"
+)
+
+const scheduleSendTimeLayout = "15:04"
+
+var notificationSchedule NotificationScheduleConfig
+
+// ScheduledNotification is a Mastodon post held until SendAt. The Slack
+// message of the same notification is sent when it is detected.
+type ScheduledNotification struct {
+	Kind          NotificationKind `json:"Kind"`
+	SendAt        time.Time        `json:"SendAt"`
+	PublicMessage string           `json:"PublicMessage"`
+	CreatedAt     time.Time        `json:"CreatedAt"`
+}
+
+// scheduledSendAt returns when the public post of n should go out, or the
+// zero time to post it right away.
+func scheduledSendAt(n Notification, now time.Time) time.Time {
+	if EnvConfig.S3ScheduledNotificationsObjectKey == "" || !n.Public {
+		return time.Time{}
+	}
+
+	if notificationSchedule.SendTime == "" || !slices.Contains(notificationSchedule.Kinds, string(n.Kind)) {
+		return time.Time{}
+	}
+
+	sendTime, err := time.Parse(scheduleSendTimeLayout, notificationSchedule.SendTime)
+	if err != nil {
+		log.Printf("Ignoring invalid NotificationSchedule.SendTime %q: %v", notificationSchedule.SendTime, err)
+		return time.Time{}
+	}
+
+	jst := time.FixedZone("JST", 9*60*60)
+	day := now.In(jst)
+	if notificationSchedule.UntilReleaseDay && (textfmt.RenderContext{Now: now}).DaysUntil(n.ReleaseDate) > 0 {
+		day = n.ReleaseDate.In(jst)
+	}
+
+	sendAt := time.Date(day.Year(), day.Month(), day.Day(), sendTime.Hour(), sendTime.Minute(), 0, 0, jst)
+	if !sendAt.After(now) {
+		return time.Time{}
+	}
+	return sendAt
+}
+
+// schedulePublicPost renders the Mastodon post as of sendAt so that
+// countdowns such as "発売まであとN日" are correct when it goes out.
+func schedulePublicPost(cfg aws.Config, n Notification, now, sendAt time.Time) error {
+	scheduled := ScheduledNotification{
+		Kind:          n.Kind,
+		SendAt:        sendAt,
+		PublicMessage: n.Render(textfmt.RenderContext{Now: sendAt, TitleMaxLength: titleMaxLength.Mastodon}),
+		CreatedAt:     now,
+	}
+
+	err := updateScheduledNotifications(cfg, func(entries []ScheduledNotification) []ScheduledNotification {
+		return append(entries, scheduled)
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Scheduled %s Mastodon post for %s", n.Kind, textfmt.TimeJST(sendAt))
+	return nil
+}
+
+// SendDueNotifications posts the scheduled notifications whose SendAt has
+// passed. They are removed from the queue before posting so that a failed
+// run never posts twice. Posts that fall due during a vacation are dropped,
+// as they would not have been posted when detected either.
+func SendDueNotifications(cfg aws.Config, now time.Time) (int, error) {
+	var due []ScheduledNotification
+	err := updateScheduledNotifications(cfg, func(entries []ScheduledNotification) []ScheduledNotification {
+		var remaining []ScheduledNotification
+		due = nil
+		for _, entry := range entries {
+			if entry.SendAt.After(now) {
+				remaining = append(remaining, entry)
+			} else {
+				due = append(due, entry)
+			}
+		}
+		return remaining
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	slices.SortStableFunc(due, func(a, b ScheduledNotification) int {
+		return a.SendAt.Compare(b.SendAt)
+	})
+	for _, entry := range due {
+		if IsOnVacation(now) {
+			log.Printf("Vacation mode: dropping scheduled %s Mastodon post", entry.Kind)
+			continue
+		}
+
+		log.Println(entry.PublicMessage)
+		countRunNotification()
+		if _, err := TootMastodon(entry.PublicMessage); err != nil {
+			AlertToSlack(fmt.Errorf("failed to post to Mastodon: %v", err), false)
+		}
+	}
+	return len(due), nil
+}
+
+// updateScheduledNotifications applies update to the queue. Checkers append
+// to it while the scheduled-notifier drains it, so the write is conditional
+// and retried on the latest queue rather than overwriting entries.
+func updateScheduledNotifications(cfg aws.Config, update func([]ScheduledNotification) []ScheduledNotification) error {
+	err := UpdateObject(cfg, EnvConfig.S3ScheduledNotificationsObjectKey, func(body []byte) (string, error) {
+		entries, err := decodeScheduledNotifications(body)
+		if err != nil {
+			return "", err
+		}
+		return encodeScheduledNotifications(update(entries))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update scheduled notifications: %w", err)
+	}
+	return nil
+}
+
+func decodeScheduledNotifications(body []byte) ([]ScheduledNotification, error) {
+	if body == nil {
+		return nil, nil
+	}
+
+	var entries []ScheduledNotification
+	if err := json.Unmarshal(body, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func encodeScheduledNotifications(entries []ScheduledNotification) (string, error) {
+	if entries == nil {
+		entries = []ScheduledNotification{}
+	}
+
+	prettyJSON, err := json.MarshalIndent(entries, "", "    ")
+	if err != nil {
+		return "", err
+	}
+	return string(prettyJSON), nil
+}
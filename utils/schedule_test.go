@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduledSendAt(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+	EnvConfig.S3ScheduledNotificationsObjectKey = "scheduled_notifications.json"
+	defer func() { EnvConfig.S3ScheduledNotificationsObjectKey = "" }()
+
+	detectedAt := time.Date(2024, 8, 20, 2, 13, 0, 0, jst)
+	releaseDay := time.Date(2024, 8, 20, 0, 0, 0, 0, jst)
+	nextWeek := time.Date(2024, 8, 27, 0, 0, 0, 0, jst)
+
+	tests := []struct {
+		name     string
+		schedule NotificationScheduleConfig
+		n        Notification
+		now      time.Time
+		expected time.Time
+	}{
+		{
+			name:     "Disabled",
+			n:        Notification{Kind: NotificationReleaseToday, ReleaseDate: releaseDay, Public: true},
+			now:      detectedAt,
+			expected: time.Time{},
+		},
+		{
+			name:     "Held until the send time",
+			schedule: NotificationScheduleConfig{SendTime: "09:00", Kinds: []string{"release-today"}},
+			n:        Notification{Kind: NotificationReleaseToday, ReleaseDate: releaseDay, Public: true},
+			now:      detectedAt,
+			expected: time.Date(2024, 8, 20, 9, 0, 0, 0, jst),
+		},
+		{
+			name:     "Kind not held",
+			schedule: NotificationScheduleConfig{SendTime: "09:00", Kinds: []string{"release-today"}},
+			n:        Notification{Kind: NotificationSale, ReleaseDate: releaseDay, Public: true},
+			now:      detectedAt,
+			expected: time.Time{},
+		},
+		{
+			name:     "Detected after the send time",
+			schedule: NotificationScheduleConfig{SendTime: "09:00", Kinds: []string{"release-today"}},
+			n:        Notification{Kind: NotificationReleaseToday, ReleaseDate: releaseDay, Public: true},
+			now:      time.Date(2024, 8, 20, 10, 0, 0, 0, jst),
+			expected: time.Time{},
+		},
+		{
+			name:     "Held until release day",
+			schedule: NotificationScheduleConfig{SendTime: "09:00", Kinds: []string{"new-release"}, UntilReleaseDay: true},
+			n:        Notification{Kind: NotificationNewRelease, ReleaseDate: nextWeek, Public: true},
+			now:      time.Date(2024, 8, 20, 10, 0, 0, 0, jst),
+			expected: time.Date(2024, 8, 27, 9, 0, 0, 0, jst),
+		},
+		{
+			name:     "Not public",
+			schedule: NotificationScheduleConfig{SendTime: "09:00", Kinds: []string{"release-today"}},
+			n:        Notification{Kind: NotificationReleaseToday, ReleaseDate: releaseDay},
+			now:      detectedAt,
+			expected: time.Time{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notificationSchedule = tt.schedule
+			defer func() { notificationSchedule = NotificationScheduleConfig{} }()
+
+			if got := scheduledSendAt(tt.n, tt.now); !got.Equal(tt.expected) {
+				t.Errorf("scheduledSendAt() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+type SnoozeRequest struct {
+	Duration      time.Duration
+	Mute          bool
+	SaleThreshold int
+	PointPercent  int
+}
+
+func (c SaleCheckerConfig) IsSnoozed(now time.Time) bool {
+	return now.Before(c.SnoozeUntil)
+}
+
+func (c SaleCheckerConfig) IsMuted(now time.Time) bool {
+	return c.IsSnoozed(now) && c.SnoozeMuteNotifications
+}
+
+// Thresholds returns the sale threshold and point percentage in effect at
+// now. While snoozed, the snooze thresholds apply if they are higher.
+func (c SaleCheckerConfig) Thresholds(now time.Time) (int, int) {
+	saleThreshold, pointPercent := c.SaleThreshold, c.PointPercent
+	if !c.IsSnoozed(now) {
+		return saleThreshold, pointPercent
+	}
+
+	if c.SnoozeSaleThreshold > saleThreshold {
+		saleThreshold = c.SnoozeSaleThreshold
+	}
+	if c.SnoozePointPercent > pointPercent {
+		pointPercent = c.SnoozePointPercent
+	}
+	return saleThreshold, pointPercent
+}
+
+// SnoozeSales starts a snooze period and returns the updated config.
+// Thresholds left at zero in req fall back to the configured
+// SnoozeSaleThreshold and SnoozePointPercent.
+func SnoozeSales(cfg aws.Config, saleConfig SaleCheckerConfig, req SnoozeRequest, now time.Time) (SaleCheckerConfig, error) {
+	snoozed, fields, err := applySnooze(saleConfig, req, now)
+	if err != nil {
+		return saleConfig, err
+	}
+
+	if err := PatchCheckerConfigs(cfg, "SaleChecker", fields); err != nil {
+		return saleConfig, fmt.Errorf("failed to save snooze: %w", err)
+	}
+	return snoozed, nil
+}
+
+func UnsnoozeSales(cfg aws.Config) error {
+	fields := map[string]any{"SnoozeUntil": time.Time{}, "SnoozeMuteNotifications": false}
+	if err := PatchCheckerConfigs(cfg, "SaleChecker", fields); err != nil {
+		return fmt.Errorf("failed to clear snooze: %w", err)
+	}
+	return nil
+}
+
+func applySnooze(c SaleCheckerConfig, req SnoozeRequest, now time.Time) (SaleCheckerConfig, map[string]any, error) {
+	if req.Duration <= 0 {
+		return c, nil, fmt.Errorf("%w: snooze duration must be positive", ErrUsage)
+	}
+
+	c.SnoozeUntil = now.Add(req.Duration)
+	c.SnoozeMuteNotifications = req.Mute
+	fields := map[string]any{"SnoozeUntil": c.SnoozeUntil, "SnoozeMuteNotifications": c.SnoozeMuteNotifications}
+
+	if req.SaleThreshold > 0 {
+		c.SnoozeSaleThreshold = req.SaleThreshold
+		fields["SnoozeSaleThreshold"] = req.SaleThreshold
+	}
+	if req.PointPercent > 0 {
+		c.SnoozePointPercent = req.PointPercent
+		fields["SnoozePointPercent"] = req.PointPercent
+	}
+
+	if !req.Mute && c.SnoozeSaleThreshold <= c.SaleThreshold && c.SnoozePointPercent <= c.PointPercent {
+		return c, nil, fmt.Errorf("%w: snoozing would not raise any threshold; give higher snooze thresholds or mute", ErrUsage)
+	}
+	return c, fields, nil
+}
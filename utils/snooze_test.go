@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSaleCheckerConfigThresholds(t *testing.T) {
+	now := time.Date(2024, 8, 20, 9, 0, 0, 0, time.UTC)
+	base := SaleCheckerConfig{SaleThreshold: 200, PointPercent: 20, SnoozeSaleThreshold: 500, SnoozePointPercent: 50}
+
+	tests := []struct {
+		name          string
+		snoozeUntil   time.Time
+		snoozeSale    int
+		snoozePercent int
+		expectedSale  int
+		expectedPct   int
+	}{
+		{"Not snoozed", time.Time{}, 500, 50, 200, 20},
+		{"Snooze expired", now.Add(-time.Hour), 500, 50, 200, 20},
+		{"Snoozed", now.Add(time.Hour), 500, 50, 500, 50},
+		{"Lower snooze thresholds are ignored", now.Add(time.Hour), 100, 10, 200, 20},
+		{"Only the sale threshold raised", now.Add(time.Hour), 500, 0, 500, 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := base
+			c.SnoozeUntil, c.SnoozeSaleThreshold, c.SnoozePointPercent = tt.snoozeUntil, tt.snoozeSale, tt.snoozePercent
+
+			sale, pct := c.Thresholds(now)
+			if sale != tt.expectedSale || pct != tt.expectedPct {
+				t.Errorf("Thresholds() = %d, %d, expected %d, %d", sale, pct, tt.expectedSale, tt.expectedPct)
+			}
+		})
+	}
+}
+
+func TestApplySnooze(t *testing.T) {
+	now := time.Date(2024, 8, 20, 9, 0, 0, 0, time.UTC)
+	base := SaleCheckerConfig{SaleThreshold: 200, PointPercent: 20}
+
+	tests := []struct {
+		name         string
+		config       SaleCheckerConfig
+		req          SnoozeRequest
+		expectedSale int
+		expectedPct  int
+		wantErr      bool
+	}{
+		{"No snooze thresholds configured", base, SnoozeRequest{Duration: time.Hour}, 0, 0, true},
+		{"Mute needs no thresholds", base, SnoozeRequest{Duration: time.Hour, Mute: true}, 200, 20, false},
+		{"Thresholds from the request", base, SnoozeRequest{Duration: time.Hour, SaleThreshold: 800, PointPercent: 60}, 800, 60, false},
+		{"Thresholds from the config", SaleCheckerConfig{SaleThreshold: 200, PointPercent: 20, SnoozePointPercent: 50}, SnoozeRequest{Duration: time.Hour}, 200, 50, false},
+		{"No duration", base, SnoozeRequest{Mute: true}, 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, fields, err := applySnooze(tt.config, tt.req, now)
+			if tt.wantErr {
+				if !errors.Is(err, ErrUsage) {
+					t.Errorf("applySnooze() error = %v, expected ErrUsage", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applySnooze() error = %v", err)
+			}
+
+			if !c.SnoozeUntil.Equal(now.Add(time.Hour)) || fields["SnoozeUntil"] != c.SnoozeUntil {
+				t.Errorf("SnoozeUntil = %v, fields = %v", c.SnoozeUntil, fields)
+			}
+			if sale, pct := c.Thresholds(now); sale != tt.expectedSale || pct != tt.expectedPct {
+				t.Errorf("Thresholds() = %d, %d, expected %d, %d", sale, pct, tt.expectedSale, tt.expectedPct)
+			}
+		})
+	}
+}
@@ -0,0 +1,357 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	billy "github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+const (
+	DatasetStoreS3  = "s3"
+	DatasetStoreGit = "git"
+)
+
+var (
+	ErrObjectNotFound = errors.New("object not found")
+	ErrObjectChanged  = errors.New("object changed since it was read")
+)
+
+const updateObjectMaxAttempts = 5
+
+type DatasetStore interface {
+	Get(objectKey string) ([]byte, error)
+	Put(objectKey, body string) error
+}
+
+// versionedStore is implemented by stores that can write an object only if
+// it has not changed since it was read. The version is opaque to callers.
+type versionedStore interface {
+	GetVersion(objectKey string) ([]byte, string, error)
+	PutIfVersion(objectKey, body, version string) error
+}
+
+var (
+	datasetStoreMu sync.Mutex
+	datasetStore   DatasetStore
+)
+
+func NewDatasetStore(cfg aws.Config) (DatasetStore, error) {
+	switch EnvConfig.DatasetStore {
+	case "", DatasetStoreS3:
+		return s3Store{cfg: cfg}, nil
+	case DatasetStoreGit:
+		if EnvConfig.GitDatasetURL == "" {
+			return nil, fmt.Errorf("%w: GitDatasetURL is required for the %q dataset store", ErrConfig, DatasetStoreGit)
+		}
+		token := EnvConfig.GitDatasetToken
+		if token == "" {
+			token = EnvConfig.GitHubToken
+		}
+		return &gitStore{
+			s3:      s3Store{cfg: cfg},
+			url:     EnvConfig.GitDatasetURL,
+			branch:  EnvConfig.GitDatasetBranch,
+			dir:     EnvConfig.GitDatasetPath,
+			auth:    &githttp.BasicAuth{Username: "git", Password: token},
+			pending: map[string]string{},
+		}, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown dataset store: %s", ErrConfig, EnvConfig.DatasetStore)
+	}
+}
+
+func currentDatasetStore(cfg aws.Config) (DatasetStore, error) {
+	datasetStoreMu.Lock()
+	defer datasetStoreMu.Unlock()
+
+	if datasetStore == nil {
+		store, err := NewDatasetStore(cfg)
+		if err != nil {
+			return nil, err
+		}
+		if IsPAAPIMock() {
+			store = &mockDatasetStore{base: store, writes: map[string]string{}}
+		}
+		datasetStore = store
+	}
+	return datasetStore, nil
+}
+
+// FlushDatasets writes out the changes buffered by the dataset store and
+// drops it, so the next invocation starts from the latest stored state.
+func FlushDatasets() error {
+	datasetStoreMu.Lock()
+	store := datasetStore
+	datasetStore = nil
+	datasetStoreMu.Unlock()
+
+	if flusher, ok := store.(interface{ Flush() error }); ok {
+		return flusher.Flush()
+	}
+	return nil
+}
+
+func GetObject(cfg aws.Config, objectKey string) ([]byte, error) {
+	warnDeprecatedKey(objectKey)
+	store, err := currentDatasetStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return store.Get(objectKey)
+}
+
+func PutObject(cfg aws.Config, body, objectKey string) error {
+	warnDeprecatedKey(objectKey)
+	store, err := currentDatasetStore(cfg)
+	if err != nil {
+		return err
+	}
+	return store.Put(objectKey, body)
+}
+
+// UpdateObject read-modify-writes an object that several checkers write
+// concurrently. update receives nil when the object does not exist yet; when
+// another run writes the object in between, the update is retried on the new
+// contents instead of overwriting them.
+func UpdateObject(cfg aws.Config, objectKey string, update func(body []byte) (string, error)) error {
+	warnDeprecatedKey(objectKey)
+	store, err := currentDatasetStore(cfg)
+	if err != nil {
+		return err
+	}
+
+	versioned, ok := store.(versionedStore)
+	if !ok {
+		body, err := store.Get(objectKey)
+		if err != nil && !errors.Is(err, ErrObjectNotFound) {
+			return err
+		}
+		updated, err := update(body)
+		if err != nil {
+			return err
+		}
+		return store.Put(objectKey, updated)
+	}
+
+	for attempt := 1; ; attempt++ {
+		body, version, err := versioned.GetVersion(objectKey)
+		if err != nil && !errors.Is(err, ErrObjectNotFound) {
+			return err
+		}
+		updated, err := update(body)
+		if err != nil {
+			return err
+		}
+
+		err = versioned.PutIfVersion(objectKey, updated, version)
+		if !errors.Is(err, ErrObjectChanged) || attempt == updateObjectMaxAttempts {
+			return err
+		}
+		log.Printf("%s was written by another run, retrying (%d/%d)", objectKey, attempt, updateObjectMaxAttempts)
+	}
+}
+
+// CheckerObjectKey derives the per-checker object key for run state, e.g.
+// "state/warm_up.json" becomes "state/warm_up.sale-checker.json".
+func CheckerObjectKey(base, checker string) string {
+	ext := path.Ext(base)
+	return strings.TrimSuffix(base, ext) + "." + checker + ext
+}
+
+type s3Store struct {
+	cfg aws.Config
+}
+
+func (s s3Store) Get(objectKey string) ([]byte, error) {
+	return GetS3Object(s.cfg, objectKey)
+}
+
+func (s s3Store) Put(objectKey, body string) error {
+	return PutS3Object(s.cfg, body, objectKey)
+}
+
+func (s s3Store) GetVersion(objectKey string) ([]byte, string, error) {
+	return GetS3ObjectVersion(s.cfg, objectKey)
+}
+
+func (s s3Store) PutIfVersion(objectKey, body, version string) error {
+	return PutS3ObjectIfVersion(s.cfg, body, objectKey, version)
+}
+
+// gitStore keeps the hand-edited datasets in a Git repository. Writes are
+// buffered and pushed as one commit by Flush; progress indices and other run
+// state change on almost every run and stay in S3.
+type gitStore struct {
+	s3      s3Store
+	url     string
+	branch  string
+	dir     string
+	auth    *githttp.BasicAuth
+	repo    *git.Repository
+	fs      billy.Filesystem
+	pending map[string]string
+}
+
+func gitVersionedObjectKeys() []string {
+	return []string{
+		EnvConfig.S3UnprocessedObjectKey,
+		EnvConfig.S3PaperBooksObjectKey,
+		EnvConfig.S3AuthorsObjectKey,
+		EnvConfig.S3ExcludedTitleKeywordsObjectKey,
+		EnvConfig.S3NotifiedObjectKey,
+		EnvConfig.S3UpcomingObjectKey,
+		EnvConfig.S3AudibleNotifiedObjectKey,
+		EnvConfig.S3CheckerConfigObjectKey,
+		EnvConfig.S3AuthorsAuditLogObjectKey,
+		EnvConfig.S3ArchiveObjectKey,
+	}
+}
+
+func isGitVersioned(objectKey string) bool {
+	return objectKey != "" && slices.Contains(gitVersionedObjectKeys(), objectKey)
+}
+
+func (g *gitStore) clone() error {
+	fs := memfs.New()
+	opts := &git.CloneOptions{URL: g.url, Auth: g.auth, SingleBranch: true}
+	if g.branch != "" {
+		opts.ReferenceName = plumbing.NewBranchReferenceName(g.branch)
+	}
+
+	repo, err := git.Clone(memory.NewStorage(), fs, opts)
+	if err != nil {
+		return fmt.Errorf("failed to clone %s: %w", g.url, err)
+	}
+	g.repo, g.fs = repo, fs
+	return nil
+}
+
+func (g *gitStore) Get(objectKey string) ([]byte, error) {
+	if !isGitVersioned(objectKey) {
+		return g.s3.Get(objectKey)
+	}
+	if body, ok := g.pending[objectKey]; ok {
+		return []byte(body), nil
+	}
+
+	if g.repo == nil {
+		if err := g.clone(); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := util.ReadFile(g.fs, path.Join(g.dir, objectKey))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%s: %w", objectKey, ErrObjectNotFound)
+	}
+	return data, err
+}
+
+func (g *gitStore) Put(objectKey, body string) error {
+	if !isGitVersioned(objectKey) {
+		return g.s3.Put(objectKey, body)
+	}
+	g.pending[objectKey] = body
+	return nil
+}
+
+// GetVersion and PutIfVersion make the S3-backed objects safe to update
+// concurrently. Git-versioned objects are buffered until Flush, which drops
+// them when the branch moved, so they carry no version.
+func (g *gitStore) GetVersion(objectKey string) ([]byte, string, error) {
+	if !isGitVersioned(objectKey) {
+		return g.s3.GetVersion(objectKey)
+	}
+	body, err := g.Get(objectKey)
+	return body, "", err
+}
+
+func (g *gitStore) PutIfVersion(objectKey, body, version string) error {
+	if !isGitVersioned(objectKey) {
+		return g.s3.PutIfVersion(objectKey, body, version)
+	}
+	return g.Put(objectKey, body)
+}
+
+// Flush commits every buffered write at once. The buffered bodies are whole
+// files built from the clone read during the run, so if the branch moved in
+// the meantime they are dropped rather than pushed over the upstream edits.
+func (g *gitStore) Flush() error {
+	if len(g.pending) == 0 {
+		return nil
+	}
+
+	err := g.commitAndPush()
+	if isNonFastForward(err) {
+		keys := slices.Sorted(maps.Keys(g.pending))
+		g.pending = map[string]string{}
+		err = fmt.Errorf("%w: %s moved during the run, dropped the changes to %s: %w", ErrPartialSuccess, g.url, strings.Join(keys, ", "), err)
+		RaiseCriticalAlert(g.s3.cfg, "dataset-push:"+g.url, err)
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to push datasets to %s: %w", g.url, err)
+	}
+
+	g.pending = map[string]string{}
+	return nil
+}
+
+// isNonFastForward reports a push rejected because the branch moved. go-git
+// reports it with a plain error that does not wrap ErrNonFastForwardUpdate.
+func isNonFastForward(err error) bool {
+	return err != nil && (errors.Is(err, git.ErrNonFastForwardUpdate) || strings.Contains(err.Error(), "non-fast-forward update"))
+}
+
+func (g *gitStore) commitAndPush() error {
+	if g.repo == nil {
+		if err := g.clone(); err != nil {
+			return err
+		}
+	}
+
+	worktree, err := g.repo.Worktree()
+	if err != nil {
+		return err
+	}
+
+	keys := slices.Sorted(maps.Keys(g.pending))
+	for _, key := range keys {
+		name := path.Join(g.dir, key)
+		if err := util.WriteFile(g.fs, name, []byte(g.pending[key]), 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+		if _, err := worktree.Add(name); err != nil {
+			return fmt.Errorf("failed to stage %s: %w", name, err)
+		}
+	}
+
+	_, err = worktree.Commit("Update "+strings.Join(keys, ", "), &git.CommitOptions{
+		Author: &object.Signature{Name: "kindle_bot", Email: "kindle_bot@users.noreply.github.com", When: time.Now()},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to commit datasets: %w", err)
+	}
+
+	if err := g.repo.Push(&git.PushOptions{Auth: g.auth}); err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return err
+	}
+	return nil
+}
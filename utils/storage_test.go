@@ -0,0 +1,161 @@
+package utils
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestGitStoreBuffersVersionedWrites(t *testing.T) {
+	EnvConfig.S3UnprocessedObjectKey = "unprocessed.json"
+	EnvConfig.S3PrevIndexSaleCheckerObjectKey = "prev_index_sale.json"
+	defer func() {
+		EnvConfig.S3UnprocessedObjectKey = ""
+		EnvConfig.S3PrevIndexSaleCheckerObjectKey = ""
+	}()
+
+	tests := []struct {
+		objectKey string
+		versioned bool
+	}{
+		{"unprocessed.json", true},
+		{"prev_index_sale.json", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := isGitVersioned(tt.objectKey); got != tt.versioned {
+			t.Errorf("isGitVersioned(%q) = %v, expected %v", tt.objectKey, got, tt.versioned)
+		}
+	}
+
+	store := &gitStore{pending: map[string]string{}}
+	if err := store.Put("unprocessed.json", "[1]"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put("unprocessed.json", "[1,2]"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := store.Get("unprocessed.json")
+	if err != nil || string(got) != "[1,2]" {
+		t.Errorf("Get() = %q, %v, expected the latest buffered write", got, err)
+	}
+	if len(store.pending) != 1 {
+		t.Errorf("pending = %v, expected one buffered file", store.pending)
+	}
+}
+
+// racyStore is a versioned store in which another run appends to the object
+// between the first read and write.
+type racyStore struct {
+	body    string
+	version int
+	raced   bool
+}
+
+func (r *racyStore) Get(string) ([]byte, error)      { return []byte(r.body), nil }
+func (r *racyStore) Put(_ string, body string) error { r.body = body; return nil }
+
+func (r *racyStore) GetVersion(string) ([]byte, string, error) {
+	return []byte(r.body), strconv.Itoa(r.version), nil
+}
+
+func (r *racyStore) PutIfVersion(_ string, body, version string) error {
+	if !r.raced {
+		r.raced = true
+		r.body += "b"
+		r.version++
+	}
+	if version != strconv.Itoa(r.version) {
+		return ErrObjectChanged
+	}
+	r.body = body
+	r.version++
+	return nil
+}
+
+func TestUpdateObjectRetriesOnConflict(t *testing.T) {
+	store := &racyStore{body: "a"}
+	datasetStore = store
+	defer func() { datasetStore = nil }()
+
+	err := UpdateObject(aws.Config{}, "scheduled.json", func(body []byte) (string, error) {
+		return string(body) + "c", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.body != "abc" {
+		t.Errorf("body = %q, expected the concurrent write to be kept", store.body)
+	}
+}
+
+func TestGitStoreFlushKeepsUpstreamEdits(t *testing.T) {
+	// Mock mode keeps the critical alert away from Slack.
+	EnvConfig.PAAPIMode = PAAPIModeMock
+	EnvConfig.S3UnprocessedObjectKey = "unprocessed.json"
+	defer func() {
+		EnvConfig.PAAPIMode = ""
+		EnvConfig.S3UnprocessedObjectKey = ""
+	}()
+
+	remote := t.TempDir()
+	if _, err := git.PlainInit(remote, true); err != nil {
+		t.Fatal(err)
+	}
+	upstream := t.TempDir()
+	repo, err := git.PlainInit(upstream, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{remote}}); err != nil {
+		t.Fatal(err)
+	}
+	commitUpstream := func(body string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(upstream, "unprocessed.json"), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		worktree, err := repo.Worktree()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := worktree.Add("unprocessed.json"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := worktree.Commit("edit", &git.CommitOptions{Author: &object.Signature{Name: "editor", When: time.Now()}}); err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.Push(&git.PushOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	commitUpstream(`["A"]`)
+
+	store := &gitStore{url: remote, pending: map[string]string{}}
+	if _, err := store.Get("unprocessed.json"); err != nil {
+		t.Fatal(err)
+	}
+	commitUpstream(`["A","B"]`)
+
+	if err := store.Put("unprocessed.json", `["A","C"]`); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Flush(); !errors.Is(err, ErrPartialSuccess) {
+		t.Fatalf("Flush() = %v, expected a partial success", err)
+	}
+
+	fresh := &gitStore{url: remote, pending: map[string]string{}}
+	got, err := fresh.Get("unprocessed.json")
+	if err != nil || string(got) != `["A","B"]` {
+		t.Errorf("remote = %s, %v, expected the upstream edit to be kept", got, err)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"log"
@@ -20,6 +22,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
@@ -28,6 +31,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/smithy-go"
 
 	"github.com/goark/errs"
 	paapi5 "github.com/goark/pa-api"
@@ -35,24 +39,46 @@ import (
 	"github.com/goark/pa-api/query"
 	"github.com/mattn/go-mastodon"
 	"github.com/slack-go/slack"
+
+	"kindle_bot/render/textfmt"
 )
 
 var (
 	EnvConfig Config
 
-	configInitErr error
-	once          sync.Once
-	reportFailure bool = true
+	configInitErr  error
+	once           sync.Once
+	reportFailure  bool = true
+	titleMaxLength TitleMaxLengthConfig
+	routing        NotificationRoutingConfig
+	vacation       VacationConfig
 )
 
+const slackMentionUserID = "U0MHY7ATX"
+
 func Run(process func() error) {
+	if !flag.Parsed() {
+		flag.Parse()
+	}
+
 	if err := initConfig(); err != nil {
 		log.Println("Error loading configuration:", err)
+		if !IsLambda() {
+			os.Exit(ExitConfigError)
+		}
 		return
 	}
 
+	checker := checkerName()
+	var processErr error
 	handler := func(ctx context.Context) (string, error) {
-		err := process()
+		startRun(checker, time.Now())
+		processErr = process()
+		if err := FlushDatasets(); err != nil && processErr == nil {
+			processErr = err
+		}
+		processErr = notifiedBeforeFailure(processErr, currentRun.Notifications)
+		err := processErr
 		if err != nil {
 			if reportFailure {
 				AlertToSlack(err, false)
@@ -61,16 +87,46 @@ func Run(process func() error) {
 				err = nil
 			}
 		}
+		if IsLambda() {
+			finishRun(processErr)
+			recordCircuitBreaker(checker, processErr)
+		}
 		return "Processing complete: " + getFilename(), err
 	}
 
 	if IsLambda() {
 		lambda.Start(handler)
-	} else {
-		handler(context.Background())
+		return
+	}
+
+	handler(context.Background())
+	if code := ExitCode(processErr); code != ExitOK {
+		os.Exit(code)
 	}
 }
 
+func RunFunctionURL(handler func(ctx context.Context, req events.LambdaFunctionURLRequest) (events.LambdaFunctionURLResponse, error)) {
+	if err := initConfig(); err != nil {
+		log.Println("Error loading configuration:", err)
+		return
+	}
+
+	if !IsLambda() {
+		log.Println("This function only runs behind a Lambda Function URL")
+		return
+	}
+	lambda.Start(func(ctx context.Context, req events.LambdaFunctionURLRequest) (events.LambdaFunctionURLResponse, error) {
+		resp, err := handler(ctx, req)
+		if flushErr := FlushDatasets(); flushErr != nil {
+			log.Println("Error saving datasets:", flushErr)
+			if err == nil {
+				err = flushErr
+			}
+		}
+		return resp, err
+	})
+}
+
 func initConfig() error {
 	if IsLambda() {
 		once.Do(func() {
@@ -99,10 +155,19 @@ func initConfig() error {
 				S3ExcludedTitleKeywordsObjectKey:  paramMap["S3_EXCLUDED_TITLE_KEYWORDS_OBJECT_KEY"],
 				S3NotifiedObjectKey:               paramMap["S3_NOTIFIED_OBJECT_KEY"],
 				S3UpcomingObjectKey:               paramMap["S3_UPCOMING_OBJECT_KEY"],
+				S3AudibleNotifiedObjectKey:        paramMap["S3_AUDIBLE_NOTIFIED_OBJECT_KEY"],
 				S3PrevIndexNewReleaseObjectKey:    paramMap["S3_PREV_INDEX_NEW_RELEASE_OBJECT_KEY"],
 				S3PrevIndexPaperToKindleObjectKey: paramMap["S3_PREV_INDEX_PAPER_TO_KINDLE_OBJECT_KEY"],
 				S3PrevIndexSaleCheckerObjectKey:   paramMap["S3_PREV_INDEX_SALE_CHECKER_OBJECT_KEY"],
 				S3CheckerConfigObjectKey:          paramMap["S3_CHECKER_CONFIG_OBJECT_KEY"],
+				S3VacationDigestObjectKey:         paramMap["S3_VACATION_DIGEST_OBJECT_KEY"],
+				S3WarmUpStateObjectKey:            paramMap["S3_WARM_UP_STATE_OBJECT_KEY"],
+				S3CriticalAlertsObjectKey:         paramMap["S3_CRITICAL_ALERTS_OBJECT_KEY"],
+				S3RunHistoryObjectKey:             paramMap["S3_RUN_HISTORY_OBJECT_KEY"],
+				S3AuthorsAuditLogObjectKey:        paramMap["S3_AUTHORS_AUDIT_LOG_OBJECT_KEY"],
+				S3PendingApprovalsObjectKey:       paramMap["S3_PENDING_APPROVALS_OBJECT_KEY"],
+				S3ArchiveObjectKey:                paramMap["S3_ARCHIVE_OBJECT_KEY"],
+				S3ScheduledNotificationsObjectKey: paramMap["S3_SCHEDULED_NOTIFICATIONS_OBJECT_KEY"],
 				S3Region:                          paramMap["S3_REGION"],
 				AmazonPartnerTag:                  paramMap["AMAZON_PARTNER_TAG"],
 				AmazonAccessKey:                   paramMap["AMAZON_ACCESS_KEY"],
@@ -114,7 +179,16 @@ func initConfig() error {
 				SlackBotToken:                     paramMap["SLACK_BOT_TOKEN"],
 				SlackNoticeChannel:                paramMap["SLACK_NOTICE_CHANNEL"],
 				SlackErrorChannel:                 paramMap["SLACK_ERROR_CHANNEL"],
+				SlackPriorityChannel:              paramMap["SLACK_PRIORITY_CHANNEL"],
+				SlackSigningSecret:                paramMap["SLACK_SIGNING_SECRET"],
 				GitHubToken:                       paramMap["GITHUB_TOKEN"],
+				DatasetStore:                      paramMap["DATASET_STORE"],
+				GitDatasetURL:                     paramMap["GIT_DATASET_URL"],
+				GitDatasetBranch:                  paramMap["GIT_DATASET_BRANCH"],
+				GitDatasetPath:                    paramMap["GIT_DATASET_PATH"],
+				GitDatasetToken:                   paramMap["GIT_DATASET_TOKEN"],
+				PAAPIMode:                         paramMap["PAAPI_MODE"],
+				PAAPIMockFixtureDir:               paramMap["PAAPI_MOCK_FIXTURE_DIR"],
 			}
 		})
 	} else {
@@ -205,20 +279,25 @@ func InitAWSConfig() (aws.Config, error) {
 		config.WithRegion(EnvConfig.S3Region),
 	)
 	if err != nil {
-		return aws.Config{}, fmt.Errorf("failed to load AWS config: %v", err)
+		return aws.Config{}, fmt.Errorf("%w: failed to load AWS config: %v", ErrConfig, err)
 	}
 	return cfg, nil
 }
 
-func CreateClient() paapi5.Client {
+func CreateClient() (paapi5.Client, error) {
+	httpClient, err := newPAAPIHTTPClient()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrConfig, err)
+	}
+
 	return paapi5.New(
 		paapi5.WithMarketplace(paapi5.LocaleJapan),
 	).CreateClient(
 		EnvConfig.AmazonPartnerTag,
 		EnvConfig.AmazonAccessKey,
 		EnvConfig.AmazonSecretKey,
-		paapi5.WithHttpClient(&http.Client{}),
-	)
+		paapi5.WithHttpClient(httpClient),
+	), nil
 }
 
 func GetS3Object(cfg aws.Config, objectKey string) ([]byte, error) {
@@ -231,6 +310,10 @@ func GetS3Object(cfg aws.Config, objectKey string) ([]byte, error) {
 
 	resp, err := client.GetObject(context.TODO(), input)
 	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, fmt.Errorf("%s: %w", objectKey, ErrObjectNotFound)
+		}
 		return nil, err
 	}
 	defer resp.Body.Close()
@@ -238,6 +321,54 @@ func GetS3Object(cfg aws.Config, objectKey string) ([]byte, error) {
 	return io.ReadAll(resp.Body)
 }
 
+// GetS3ObjectVersion is GetS3Object that also returns the ETag, for a later
+// PutS3ObjectIfVersion.
+func GetS3ObjectVersion(cfg aws.Config, objectKey string) ([]byte, string, error) {
+	client := s3.NewFromConfig(cfg)
+
+	resp, err := client.GetObject(context.TODO(), &s3.GetObjectInput{
+		Bucket: aws.String(EnvConfig.S3BucketName),
+		Key:    aws.String(objectKey),
+	})
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, "", fmt.Errorf("%s: %w", objectKey, ErrObjectNotFound)
+		}
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	return body, aws.ToString(resp.ETag), err
+}
+
+// PutS3ObjectIfVersion writes the object only if its ETag still matches
+// version, or only if it does not exist yet when version is empty.
+func PutS3ObjectIfVersion(cfg aws.Config, body, objectKey, version string) error {
+	client := s3.NewFromConfig(cfg)
+
+	input := &s3.PutObjectInput{
+		Bucket:      aws.String(EnvConfig.S3BucketName),
+		Key:         aws.String(objectKey),
+		Body:        strings.NewReader(body),
+		ACL:         types.ObjectCannedACLPrivate,
+		ContentType: aws.String("application/json"),
+	}
+	if version == "" {
+		input.IfNoneMatch = aws.String("*")
+	} else {
+		input.IfMatch = aws.String(version)
+	}
+
+	_, err := client.PutObject(context.TODO(), input)
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && (apiErr.ErrorCode() == "PreconditionFailed" || apiErr.ErrorCode() == "ConditionalRequestConflict") {
+		return fmt.Errorf("%s: %w", objectKey, ErrObjectChanged)
+	}
+	return err
+}
+
 func PutS3Object(cfg aws.Config, body, objectKey string) error {
 	client := s3.NewFromConfig(cfg)
 
@@ -252,7 +383,7 @@ func PutS3Object(cfg aws.Config, body, objectKey string) error {
 }
 
 func FetchASINs(cfg aws.Config, objectKey string) ([]KindleBook, error) {
-	body, err := GetS3Object(cfg, objectKey)
+	body, err := GetObject(cfg, objectKey)
 	if err != nil {
 		return nil, err
 	}
@@ -266,21 +397,82 @@ func FetchASINs(cfg aws.Config, objectKey string) ([]KindleBook, error) {
 }
 
 func FetchCheckerConfigs(cfg aws.Config) (*CheckerConfigs, error) {
-	body, err := GetS3Object(cfg, EnvConfig.S3CheckerConfigObjectKey)
+	body, err := GetObject(cfg, EnvConfig.S3CheckerConfigObjectKey)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %w", ErrConfig, err)
 	}
 
 	var configs CheckerConfigs
 	if err := json.Unmarshal(body, &configs); err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %s: %w", ErrConfig, EnvConfig.S3CheckerConfigObjectKey, err)
 	}
 
 	reportFailure = configs.ReportFailure
+	titleMaxLength = configs.TitleMaxLength
+	routing = configs.NotificationRouting
+	notificationSchedule = configs.NotificationSchedule
+	vacation = configs.Vacation
+	warmUpConfig = configs.WarmUp
+	audibleBrowseNodeID = configs.AudibleBrowseNodeID
+	deprecatedObjectKeys = configs.DeprecatedObjectKeys
+	criticalAlertConfig = configs.CriticalAlerts
+	runHistoryConfig = configs.RunHistory
+	approvalConfig = approvalConfigFor(&configs, checkerName())
 
 	return &configs, nil
 }
 
+// PatchCheckerConfigs overwrites only the given fields of the checker
+// configs, so keys this build does not know about are kept. An empty section
+// patches top-level fields.
+func PatchCheckerConfigs(cfg aws.Config, section string, fields map[string]any) error {
+	body, err := GetObject(cfg, EnvConfig.S3CheckerConfigObjectKey)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrConfig, err)
+	}
+
+	patched, err := patchJSON(body, section, fields)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrConfig, EnvConfig.S3CheckerConfigObjectKey, err)
+	}
+
+	return PutObject(cfg, string(patched), EnvConfig.S3CheckerConfigObjectKey)
+}
+
+func patchJSON(body []byte, section string, fields map[string]any) ([]byte, error) {
+	var root map[string]json.RawMessage
+	if err := json.Unmarshal(body, &root); err != nil {
+		return nil, err
+	}
+
+	target := root
+	if section != "" {
+		target = map[string]json.RawMessage{}
+		if raw, ok := root[section]; ok {
+			if err := json.Unmarshal(raw, &target); err != nil {
+				return nil, fmt.Errorf("%s: %w", section, err)
+			}
+		}
+	}
+
+	for key, value := range fields {
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		target[key] = raw
+	}
+
+	if section != "" {
+		raw, err := json.Marshal(target)
+		if err != nil {
+			return nil, err
+		}
+		root[section] = raw
+	}
+	return json.MarshalIndent(root, "", "  ")
+}
+
 func UniqueASINs(slice []KindleBook) []KindleBook {
 	seen := make(map[string]struct{})
 	result := []KindleBook{}
@@ -315,13 +507,49 @@ func GetBook(ASIN string, slice []KindleBook) KindleBook {
 	return KindleBook{}
 }
 
+const (
+	BindingKindle  = "Kindle版"
+	BindingAudible = "Audible版"
+)
+
+func IsKindleItem(item entity.Item) bool {
+	return itemBinding(item) == BindingKindle
+}
+
+func IsAudibleItem(item entity.Item) bool {
+	return itemBinding(item) == BindingAudible
+}
+
+func ItemContributors(item entity.Item) string {
+	if item.ItemInfo == nil || item.ItemInfo.ByLineInfo == nil {
+		return ""
+	}
+
+	var names []string
+	for _, c := range item.ItemInfo.ByLineInfo.Contributors {
+		names = append(names, c.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+func itemBinding(item entity.Item) string {
+	if item.ItemInfo == nil || item.ItemInfo.Classifications == nil {
+		return ""
+	}
+	return item.ItemInfo.Classifications.Binding.DisplayValue
+}
+
 func MakeBook(item entity.Item, maxPrice float64) KindleBook {
 	book := KindleBook{
-		ASIN:         item.ASIN,
-		Title:        item.ItemInfo.Title.DisplayValue,
-		CurrentPrice: (*item.Offers.Listings)[0].Price.Amount,
-		MaxPrice:     (*item.Offers.Listings)[0].Price.Amount,
-		URL:          item.DetailPageURL,
+		ASIN:  item.ASIN,
+		Title: item.ItemInfo.Title.DisplayValue,
+		URL:   item.DetailPageURL,
+	}
+
+	if offer, ok := SelectOffer(item); ok {
+		book.CurrentPrice = offer.Price
+		book.MaxPrice = offer.Price
+		book.OfferCount = offer.Count
 	}
 
 	if item.ItemInfo.ProductInfo.ReleaseDate != nil {
@@ -335,6 +563,46 @@ func MakeBook(item entity.Item, maxPrice float64) KindleBook {
 	return book
 }
 
+const (
+	SourceNewRelease    = "new-release-checker"
+	SourcePaperToKindle = "paper-to-kindle-checker"
+	SourceSale          = "sale-checker"
+	SourceManual        = "manual"
+	SourceImport        = "import"
+)
+
+func MarkSource(book KindleBook, source string, now time.Time) KindleBook {
+	if book.Source == "" {
+		book.Source = source
+	}
+	if book.AddedAt == "" {
+		book.AddedAt = now.In(time.FixedZone("JST", 9*60*60)).Format("2006-01-02")
+	}
+	return book
+}
+
+func CarryOverTracking(from, to KindleBook) KindleBook {
+	to.Source = from.Source
+	to.AddedAt = from.AddedAt
+	to.WatchUntil = from.WatchUntil
+	to.TrackAudible = from.TrackAudible
+	return to
+}
+
+func IsOverPriceCap(price float64, priceCap int) bool {
+	return priceCap > 0 && price > float64(priceCap)
+}
+
+func FilterOverPriceCap(books []KindleBook, priceCap int) []KindleBook {
+	var result []KindleBook
+	for _, book := range books {
+		if book.OverPriceCap || IsOverPriceCap(book.CurrentPrice, priceCap) {
+			result = append(result, book)
+		}
+	}
+	return result
+}
+
 func GetItems(cfg aws.Config, client paapi5.Client, asinChunk []string, initialRetrySeconds int, retryCount int) (*entity.Response, error) {
 	q := query.NewGetItems(client.Marketplace(), client.PartnerTag(), client.PartnerType()).
 		ASINs(asinChunk).
@@ -354,23 +622,6 @@ func GetItems(cfg aws.Config, client paapi5.Client, asinChunk []string, initialR
 	return res, nil
 }
 
-func CreateSearchQuery(client paapi5.Client, searchKey query.RequestFilter, searchValue string, maxPrice float64) *query.SearchItems {
-	q := query.NewSearchItems(client.Marketplace(), client.PartnerTag(), client.PartnerType()).
-		Search(searchKey, searchValue).
-		Request(query.SearchIndex, "KindleStore").
-		Request(query.SortBy, "NewestArrivals").
-		Request(query.BrowseNodeID, "2293143051").
-		Request(query.MinPrice, 22100).
-		EnableItemInfo().
-		EnableOffers()
-
-	if maxPrice > 0 {
-		q = q.Request(query.MaxPrice, maxPrice)
-	}
-
-	return q
-}
-
 func SearchItems(cfg aws.Config, client paapi5.Client, q *query.SearchItems, maxRetryCount int, initialRetrySeconds int) (*entity.Response, error) {
 	body, err := requestWithBackoff(cfg, client, q, maxRetryCount, initialRetrySeconds)
 	if err != nil {
@@ -387,8 +638,11 @@ func SearchItems(cfg aws.Config, client paapi5.Client, q *query.SearchItems, max
 
 func requestWithBackoff[T paapi5.Query](cfg aws.Config, client paapi5.Client, q T, maxRetryCount int, initialRetrySeconds int) ([]byte, error) {
 	const maxWait = 30 * time.Second
+	initialRetrySeconds = warmUpRetrySeconds(initialRetrySeconds)
 	for i := range maxRetryCount {
+		waitBeforeRequest()
 		body, err := client.Request(q)
+		recordRequest(findStatusCode(err) == 429)
 		if err == nil {
 			PutMetric(cfg, "KindleBot/Usage", "PAAPISuccess")
 			return body, nil
@@ -398,6 +652,9 @@ func requestWithBackoff[T paapi5.Query](cfg aws.Config, client paapi5.Client, q
 		if isRetryableError(err) {
 			if i == maxRetryCount-1 {
 				PutMetric(cfg, "KindleBot/Usage", "PAAPIMaxRetriesReached")
+				if findStatusCode(err) == 429 {
+					return nil, fmt.Errorf("%w: max retries reached, last error: %w", ErrAPIQuota, err)
+				}
 				return nil, fmt.Errorf("max retries reached, last error: %w", err)
 			}
 
@@ -486,6 +743,25 @@ func SaveUpcomingASINs(cfg aws.Config, upcomingMap map[string]KindleBook) error
 	return saveASINsFromMap(cfg, upcomingMap, EnvConfig.S3UpcomingObjectKey)
 }
 
+func FetchAudibleNotifiedASINs(cfg aws.Config) (map[string]KindleBook, error) {
+	books, err := FetchASINs(cfg, EnvConfig.S3AudibleNotifiedObjectKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch Audible notified ASINs: %w", err)
+	}
+	m := make(map[string]KindleBook)
+	for _, b := range books {
+		m[b.ASIN] = b
+	}
+	return m, nil
+}
+
+func SaveAudibleNotifiedASINs(cfg aws.Config, notifiedMap map[string]KindleBook) error {
+	if len(notifiedMap) == 0 {
+		return nil
+	}
+	return saveASINsFromMap(cfg, notifiedMap, EnvConfig.S3AudibleNotifiedObjectKey)
+}
+
 func saveASINsFromMap(cfg aws.Config, m map[string]KindleBook, key string) error {
 	var list []KindleBook
 	for _, book := range m {
@@ -496,12 +772,21 @@ func saveASINsFromMap(cfg aws.Config, m map[string]KindleBook, key string) error
 }
 
 func SaveASINs(cfg aws.Config, ASINs []KindleBook, objectKey string) error {
-	prettyJSON, err := json.MarshalIndent(ASINs, "", "    ")
+	body, err := FormatASINs(ASINs)
 	if err != nil {
 		return err
 	}
 
-	return PutS3Object(cfg, strings.ReplaceAll(string(prettyJSON), `\u0026`, "&"), objectKey)
+	return PutObject(cfg, body, objectKey)
+}
+
+func FormatASINs(ASINs []KindleBook) (string, error) {
+	prettyJSON, err := json.MarshalIndent(ASINs, "", "    ")
+	if err != nil {
+		return "", err
+	}
+
+	return strings.ReplaceAll(string(prettyJSON), `\u0026`, "&"), nil
 }
 
 func ProcessSlot(cfg aws.Config, itemCount int, cycleDays float64, prevIndexKey string) (int, bool, time.Time, error) {
@@ -517,14 +802,14 @@ func ProcessSlot(cfg aws.Config, itemCount int, cycleDays float64, prevIndexKey
 
 	format := GetCountFormat(itemCount)
 
-	prevIndexBytes, err := GetS3Object(cfg, prevIndexKey)
+	prevIndexBytes, err := GetObject(cfg, prevIndexKey)
 	if err != nil {
 		return 0, false, time.Time{}, fmt.Errorf("failed to fetch prev_index: %w", err)
 	}
 	prevIndex, _ := strconv.Atoi(string(prevIndexBytes))
 
 	if prevIndex == index {
-		skipLogFormat := fmt.Sprintf("Not my slot, skipping (%s / %s), next execution: %s (%s)", format, format, FormatTimeJST(nextExecutionTime), FormatExecutionInterval(nextExecutionTime))
+		skipLogFormat := fmt.Sprintf("Not my slot, skipping (%s / %s), next execution: %s (%s)", format, format, textfmt.TimeJST(nextExecutionTime), FormatExecutionInterval(nextExecutionTime))
 		log.Printf(skipLogFormat, index+1, itemCount)
 		return index, false, nextExecutionTime, nil
 	}
@@ -564,8 +849,9 @@ func getNextExecutionTime(currentIndex, itemCount int, secondsPerCycle int64, cy
 	return time.Unix(nextExecutionUnix, 0)
 }
 
-func FormatTimeJST(t time.Time) string {
-	return t.In(time.FixedZone("JST", 9*60*60)).Format("2006-01-02 15:04:05")
+func MonthStartJST(t time.Time) time.Time {
+	jst := t.In(time.FixedZone("JST", 9*60*60))
+	return time.Date(jst.Year(), jst.Month(), 1, 0, 0, 0, 0, jst.Location())
 }
 
 func FormatExecutionInterval(nextExecutionTime time.Time) string {
@@ -577,27 +863,19 @@ func GetCountFormat(itemCount int) string {
 	return fmt.Sprintf("%%0%dd", digits+1)
 }
 
-func LogAndNotify(message string, sendToSlack bool) {
-	log.Println(message)
-	if sendToSlack {
-		if _, err := TootMastodon(message); err != nil {
-			AlertToSlack(fmt.Errorf("failed to post to Mastodon: %v", err), false)
-		}
-	}
-	if err := PostToSlack(message, EnvConfig.SlackNoticeChannel); err != nil {
-		AlertToSlack(fmt.Errorf("failed to post to Slack: %v", err), false)
-	}
-}
-
 func AlertToSlack(err error, withMention bool) error {
 	if withMention {
-		return PostToSlack(fmt.Sprintf("<@U0MHY7ATX> %s\n```%v```", getFilename(), err), EnvConfig.SlackErrorChannel)
+		return PostToSlack(fmt.Sprintf("<@%s> %s\n```%v```", slackMentionUserID, getFilename(), err), EnvConfig.SlackErrorChannel)
 	} else {
 		return PostToSlack(fmt.Sprintf("%s\n```%v```", getFilename(), err), EnvConfig.SlackErrorChannel)
 	}
 }
 
 func PostToSlack(message string, targetChannel string) error {
+	if suppressOutbound("Slack "+targetChannel, message) {
+		return nil
+	}
+
 	api := slack.New(EnvConfig.SlackBotToken)
 
 	_, _, err := api.PostMessage(
@@ -608,6 +886,10 @@ func PostToSlack(message string, targetChannel string) error {
 }
 
 func TootMastodon(message string) (*mastodon.Status, error) {
+	if suppressOutbound("Mastodon", message) {
+		return nil, nil
+	}
+
 	c := mastodon.NewClient(&mastodon.Config{
 		Server:       EnvConfig.MastodonServer,
 		ClientID:     EnvConfig.MastodonClientID,
@@ -619,6 +901,10 @@ func TootMastodon(message string) (*mastodon.Status, error) {
 }
 
 func UpdateGist(gistID, filename, markdown string) error {
+	if suppressOutbound("gist "+filename, markdown) {
+		return nil
+	}
+
 	payload := GistPayload{
 		Files: GistFiles{
 			filename: {
@@ -652,21 +938,45 @@ func UpdateGist(gistID, filename, markdown string) error {
 	return nil
 }
 
-func UpdateBookGist(gistID, filename string, books []KindleBook) error {
-	var lines []string
-	for _, book := range books {
-		title := book.Title
-		if strings.Contains(title, "モンスターコミックス") {
-			title = title + " 👹"
+func PublishGist(cfg aws.Config, gistID, filename, markdown string) error {
+	if approvalConfig.Enabled {
+		current, err := FetchGist(gistID, filename)
+		if err != nil {
+			return fmt.Errorf("failed to fetch gist for approval: %w", err)
+		}
+		if held, err := holdGistForApproval(cfg, gistID, filename, current, markdown); err != nil || held {
+			return err
 		}
-		lines = append(lines, fmt.Sprintf("* [[%s]%s (%.0f円)](%s)", book.ReleaseDate.Format("2006-01-02"), title, book.CurrentPrice, book.URL))
 	}
 
-	markdown := fmt.Sprintf("## 合計 %d冊\n%s", len(books), strings.Join(lines, "\n"))
-
 	return UpdateGist(gistID, filename, markdown)
 }
 
+func FetchGist(gistID, filename string) (string, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("https://api.github.com/gists/%s", gistID), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "token "+EnvConfig.GitHubToken)
+
+	var client http.Client
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status fetching gist %s: %s", gistID, resp.Status)
+	}
+
+	var payload GistPayload
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return "", err
+	}
+	return payload.Files[filename].Content, nil
+}
+
 func PutMetric(cfg aws.Config, namespace, metricName string) error {
 	cw := cloudwatch.NewFromConfig(cfg)
 	_, err := cw.PutMetricData(context.TODO(), &cloudwatch.PutMetricDataInput{
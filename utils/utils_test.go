@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/goark/pa-api/entity"
+)
+
+func TestPatchJSON(t *testing.T) {
+	body := `{"SaleChecker": {"SaleThreshold": 200, "FutureKey": "keep"}, "Unknown": [1, 2]}`
+
+	tests := []struct {
+		name     string
+		section  string
+		fields   map[string]any
+		expected string
+	}{
+		{
+			name:     "Section field",
+			section:  "SaleChecker",
+			fields:   map[string]any{"SaleThreshold": 500},
+			expected: `{"SaleChecker": {"SaleThreshold": 500, "FutureKey": "keep"}, "Unknown": [1, 2]}`,
+		},
+		{
+			name:     "Missing section",
+			section:  "Vacation",
+			fields:   map[string]any{"Enabled": true},
+			expected: `{"SaleChecker": {"SaleThreshold": 200, "FutureKey": "keep"}, "Unknown": [1, 2], "Vacation": {"Enabled": true}}`,
+		},
+		{
+			name:     "Top-level field",
+			fields:   map[string]any{"TitleMaxLength": 40},
+			expected: `{"SaleChecker": {"SaleThreshold": 200, "FutureKey": "keep"}, "Unknown": [1, 2], "TitleMaxLength": 40}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patched, err := patchJSON([]byte(body), tt.section, tt.fields)
+			if err != nil {
+				t.Fatalf("patchJSON() error = %v", err)
+			}
+
+			var got, expected any
+			json.Unmarshal(patched, &got)
+			json.Unmarshal([]byte(tt.expected), &expected)
+			if !reflect.DeepEqual(got, expected) {
+				t.Errorf("patchJSON() = %s, expected %s", patched, tt.expected)
+			}
+		})
+	}
+}
+
+func TestIsAudibleItem(t *testing.T) {
+	tests := []struct {
+		name     string
+		item     string
+		audible  bool
+		kindle   bool
+		contribs string
+	}{
+		{
+			name:     "Audible edition",
+			item:     `{"ItemInfo": {"Classifications": {"Binding": {"DisplayValue": "Audible版"}}, "ByLineInfo": {"Contributors": [{"Name": "山田鐘人"}, {"Name": "種﨑敦美"}]}}}`,
+			audible:  true,
+			contribs: "山田鐘人, 種﨑敦美",
+		},
+		{
+			name:   "Kindle edition",
+			item:   `{"ItemInfo": {"Classifications": {"Binding": {"DisplayValue": "Kindle版"}}}}`,
+			kindle: true,
+		},
+		{
+			name: "Audio CD is not Audible",
+			item: `{"ItemInfo": {"Classifications": {"Binding": {"DisplayValue": "CD"}}}}`,
+		},
+		{
+			name: "Missing classifications",
+			item: `{"ItemInfo": {"Title": {"DisplayValue": "葬送のフリーレン"}}}`,
+		},
+		{
+			name: "Missing item info",
+			item: `{"ASIN": "B0TESTASIN"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var item entity.Item
+			if err := json.Unmarshal([]byte(tt.item), &item); err != nil {
+				t.Fatal(err)
+			}
+			if got := IsAudibleItem(item); got != tt.audible {
+				t.Errorf("IsAudibleItem() = %v, expected %v", got, tt.audible)
+			}
+			if got := IsKindleItem(item); got != tt.kindle {
+				t.Errorf("IsKindleItem() = %v, expected %v", got, tt.kindle)
+			}
+			if got := ItemContributors(item); got != tt.contribs {
+				t.Errorf("ItemContributors() = %q, expected %q", got, tt.contribs)
+			}
+		})
+	}
+}
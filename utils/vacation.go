@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func IsOnVacation(now time.Time) bool {
+	if !vacation.Enabled {
+		return false
+	}
+	return vacation.Until.IsZero() || now.Before(vacation.Until)
+}
+
+func VacationUntil() time.Time {
+	return vacation.Until
+}
+
+func FetchDigestEntries(cfg aws.Config) ([]DigestEntry, error) {
+	body, err := GetObject(cfg, EnvConfig.S3VacationDigestObjectKey)
+	if errors.Is(err, ErrObjectNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch vacation digest: %w", err)
+	}
+	return decodeDigestEntries(body)
+}
+
+// RemoveDigestEntries drops the first delivered entries, keeping any that
+// were held while the digest was being posted.
+func RemoveDigestEntries(cfg aws.Config, delivered int) error {
+	return updateDigestEntries(cfg, func(entries []DigestEntry) []DigestEntry {
+		return entries[min(delivered, len(entries)):]
+	})
+}
+
+func appendDigestEntry(cfg aws.Config, entry DigestEntry) error {
+	return updateDigestEntries(cfg, func(entries []DigestEntry) []DigestEntry {
+		return append(entries, entry)
+	})
+}
+
+// updateDigestEntries applies update to the digest. Every checker holds its
+// notifications in it during a vacation, so the write is conditional and
+// retried on the latest digest rather than overwriting entries.
+func updateDigestEntries(cfg aws.Config, update func([]DigestEntry) []DigestEntry) error {
+	err := UpdateObject(cfg, EnvConfig.S3VacationDigestObjectKey, func(body []byte) (string, error) {
+		entries, err := decodeDigestEntries(body)
+		if err != nil {
+			return "", err
+		}
+		return encodeDigestEntries(update(entries))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update vacation digest: %w", err)
+	}
+	return nil
+}
+
+func decodeDigestEntries(body []byte) ([]DigestEntry, error) {
+	if body == nil {
+		return nil, nil
+	}
+
+	var entries []DigestEntry
+	if err := json.Unmarshal(body, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func encodeDigestEntries(entries []DigestEntry) (string, error) {
+	if len(entries) == 0 {
+		entries = []DigestEntry{}
+	}
+
+	prettyJSON, err := json.MarshalIndent(entries, "", "    ")
+	if err != nil {
+		return "", err
+	}
+	return string(prettyJSON), nil
+}
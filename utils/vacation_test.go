@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestDigestEntries(t *testing.T) {
+	EnvConfig.S3VacationDigestObjectKey = "vacation_digest.json"
+	defer func() { EnvConfig.S3VacationDigestObjectKey = "" }()
+
+	store := memoryStore{}
+	datasetStore = store
+	defer func() { datasetStore = nil }()
+
+	entries, err := FetchDigestEntries(aws.Config{})
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("FetchDigestEntries() = %v, %v, expected a missing digest to be empty", entries, err)
+	}
+
+	now := time.Now()
+	for _, message := range []string{"first", "second"} {
+		if err := appendDigestEntry(aws.Config{}, DigestEntry{Kind: "sale", Message: message, CreatedAt: now}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// "second" was held after the digest run fetched the entries.
+	if err := RemoveDigestEntries(aws.Config{}, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err = FetchDigestEntries(aws.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Message != "second" {
+		t.Errorf("FetchDigestEntries() = %v, expected only the undelivered entry", entries)
+	}
+}
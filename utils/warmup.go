@@ -0,0 +1,191 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"kindle_bot/render/textfmt"
+)
+
+const warmUpMinRequests = 10
+
+var BuildID string
+
+type WarmUpState struct {
+	BuildID   string    `json:"BuildID"`
+	StartedAt time.Time `json:"StartedAt"`
+	LastRunAt time.Time `json:"LastRunAt"`
+	Requests  int       `json:"Requests"`
+	Throttled int       `json:"Throttled"`
+}
+
+var (
+	warmUpConfig    WarmUpConfig
+	warmUpObjectKey string
+	warmUpState     *WarmUpState
+	// warmUpProgress is how far the current run is through the warm-up
+	// period, from 0 (just started) to 1 (normal rates).
+	warmUpProgress = 1.0
+)
+
+// LoadWarmUp reads the warm-up state of the running checker. Each checker
+// keeps its own state object so concurrently running checkers never overwrite
+// each other.
+func LoadWarmUp(cfg aws.Config, now time.Time) error {
+	if warmUpConfig.DurationMinutes <= 0 || EnvConfig.S3WarmUpStateObjectKey == "" {
+		return nil
+	}
+
+	checker := checkerName()
+	objectKey := CheckerObjectKey(EnvConfig.S3WarmUpStateObjectKey, checker)
+	var state WarmUpState
+	body, err := GetObject(cfg, objectKey)
+	switch {
+	case errors.Is(err, ErrObjectNotFound):
+	case err != nil:
+		return fmt.Errorf("failed to fetch warm-up state: %w", err)
+	default:
+		if err := json.Unmarshal(body, &state); err != nil {
+			return fmt.Errorf("failed to decode warm-up state: %w", err)
+		}
+	}
+
+	state = advanceWarmUp(state, checker, now)
+
+	warmUpObjectKey = objectKey
+	warmUpState = &state
+	warmUpProgress = warmUpProgressAt(state, now)
+	if IsWarmingUp() {
+		duration := time.Duration(warmUpConfig.DurationMinutes) * time.Minute
+		log.Printf("Warm-up %.0f%% done, active until %s", warmUpProgress*100, textfmt.TimeJST(state.StartedAt.Add(duration)))
+		PutMetric(cfg, "KindleBot/Usage", "WarmUpActive")
+	}
+	return nil
+}
+
+func SaveWarmUp(cfg aws.Config) {
+	if warmUpState == nil {
+		return
+	}
+
+	prettyJSON, err := json.MarshalIndent(warmUpState, "", "    ")
+	if err != nil {
+		log.Println("Error encoding warm-up state:", err)
+		return
+	}
+	if err := PutObject(cfg, string(prettyJSON), warmUpObjectKey); err != nil {
+		log.Println("Error saving warm-up state:", err)
+	}
+}
+
+func IsWarmingUp() bool {
+	return warmUpProgress < 1
+}
+
+// WarmUpSegmentSize ramps the number of books per run linearly from
+// WarmUp.SegmentSize up to size over the warm-up period.
+func WarmUpSegmentSize(size int) int {
+	if !IsWarmingUp() || warmUpConfig.SegmentSize <= 0 || warmUpConfig.SegmentSize >= size {
+		return size
+	}
+	return warmUpConfig.SegmentSize + int(float64(size-warmUpConfig.SegmentSize)*warmUpProgress)
+}
+
+// warmUpRetrySeconds scales the initial retry delay by RetryWaitMultiplier at
+// the start of the warm-up, easing back to the normal delay as it progresses.
+func warmUpRetrySeconds(seconds int) int {
+	if !IsWarmingUp() || warmUpConfig.RetryWaitMultiplier <= 1 {
+		return seconds
+	}
+	multiplier := 1 + float64(warmUpConfig.RetryWaitMultiplier-1)*(1-warmUpProgress)
+	return int(math.Round(float64(seconds) * multiplier))
+}
+
+func warmUpRequestInterval() time.Duration {
+	if !IsWarmingUp() || warmUpConfig.RequestIntervalSeconds <= 0 {
+		return 0
+	}
+	interval := time.Duration(warmUpConfig.RequestIntervalSeconds) * time.Second
+	return time.Duration(float64(interval) * (1 - warmUpProgress))
+}
+
+func waitBeforeRequest() {
+	if interval := warmUpRequestInterval(); interval > 0 {
+		time.Sleep(interval)
+	}
+}
+
+func recordRequest(throttled bool) {
+	if !IsWarmingUp() {
+		return
+	}
+
+	warmUpState.Requests++
+	if throttled {
+		warmUpState.Throttled++
+	}
+}
+
+// advanceWarmUp decides whether a new warm-up period starts, is extended or
+// has finished, and stamps the state with the current build and run time.
+func advanceWarmUp(state WarmUpState, checker string, now time.Time) WarmUpState {
+	duration := time.Duration(warmUpConfig.DurationMinutes) * time.Minute
+
+	switch {
+	case state.LastRunAt.IsZero():
+		log.Printf("Warm-up started: first run of %s", checker)
+		state = restartWarmUp(state, now)
+	case BuildID != "" && state.BuildID != BuildID:
+		log.Printf("Warm-up started: new build %s deployed", BuildID)
+		state = restartWarmUp(state, now)
+	case warmUpConfig.IdleHours > 0 && now.Sub(state.LastRunAt).Hours() > warmUpConfig.IdleHours:
+		log.Printf("Warm-up started: %s was idle since %s", checker, textfmt.TimeJST(state.LastRunAt))
+		state = restartWarmUp(state, now)
+	case now.Sub(state.StartedAt) >= duration && isThrottledTooOften(state):
+		log.Printf("Warm-up extended: 429 rate %.0f%% (%d/%d) is above the threshold", throttleRate(state)*100, state.Throttled, state.Requests)
+		state = restartWarmUp(state, now)
+	case now.Sub(state.StartedAt) >= duration && state.Requests > 0:
+		log.Printf("Warm-up finished: 429 rate %.0f%% (%d/%d)", throttleRate(state)*100, state.Throttled, state.Requests)
+		state.Requests = 0
+		state.Throttled = 0
+	}
+
+	state.BuildID = BuildID
+	state.LastRunAt = now
+	return state
+}
+
+func warmUpProgressAt(state WarmUpState, now time.Time) float64 {
+	duration := time.Duration(warmUpConfig.DurationMinutes) * time.Minute
+	if duration <= 0 {
+		return 1
+	}
+	return min(max(float64(now.Sub(state.StartedAt))/float64(duration), 0), 1)
+}
+
+func restartWarmUp(state WarmUpState, now time.Time) WarmUpState {
+	state.StartedAt = now
+	state.Requests = 0
+	state.Throttled = 0
+	return state
+}
+
+func isThrottledTooOften(state WarmUpState) bool {
+	if warmUpConfig.MaxThrottleRate <= 0 || state.Requests < warmUpMinRequests {
+		return false
+	}
+	return throttleRate(state) > warmUpConfig.MaxThrottleRate
+}
+
+func throttleRate(state WarmUpState) float64 {
+	if state.Requests == 0 {
+		return 0
+	}
+	return float64(state.Throttled) / float64(state.Requests)
+}
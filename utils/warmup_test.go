@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAdvanceWarmUp(t *testing.T) {
+	warmUpConfig = WarmUpConfig{DurationMinutes: 60, IdleHours: 24, MaxThrottleRate: 0.2}
+	BuildID = "build-2"
+	defer func() {
+		warmUpConfig = WarmUpConfig{}
+		BuildID = ""
+	}()
+
+	now := time.Date(2024, 8, 20, 9, 0, 0, 0, time.UTC)
+	started := now.Add(-2 * time.Hour)
+
+	tests := []struct {
+		name        string
+		state       WarmUpState
+		wantStarted time.Time
+		wantRequest int
+	}{
+		{"first run", WarmUpState{}, now, 0},
+		{"new build", WarmUpState{BuildID: "build-1", StartedAt: started, LastRunAt: now.Add(-time.Minute)}, now, 0},
+		{"idle", WarmUpState{BuildID: "build-2", StartedAt: started, LastRunAt: now.Add(-25 * time.Hour)}, now, 0},
+		{"throttled too often", WarmUpState{BuildID: "build-2", StartedAt: started, LastRunAt: now.Add(-time.Minute), Requests: 10, Throttled: 3}, now, 0},
+		{"finished", WarmUpState{BuildID: "build-2", StartedAt: started, LastRunAt: now.Add(-time.Minute), Requests: 10, Throttled: 1}, started, 0},
+		{"in progress", WarmUpState{BuildID: "build-2", StartedAt: now.Add(-30 * time.Minute), LastRunAt: now.Add(-time.Minute), Requests: 5}, now.Add(-30 * time.Minute), 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := advanceWarmUp(tt.state, "sale-checker", now)
+			if !got.StartedAt.Equal(tt.wantStarted) || got.Requests != tt.wantRequest {
+				t.Errorf("advanceWarmUp() started %v with %d requests, expected %v with %d", got.StartedAt, got.Requests, tt.wantStarted, tt.wantRequest)
+			}
+			if got.BuildID != BuildID || !got.LastRunAt.Equal(now) {
+				t.Errorf("advanceWarmUp() = %+v, expected build and last run to be stamped", got)
+			}
+		})
+	}
+}
+
+func TestWarmUpRamp(t *testing.T) {
+	warmUpConfig = WarmUpConfig{DurationMinutes: 60, SegmentSize: 2, RequestIntervalSeconds: 10, RetryWaitMultiplier: 3}
+	defer func() {
+		warmUpConfig = WarmUpConfig{}
+		warmUpProgress = 1
+	}()
+
+	tests := []struct {
+		progress     float64
+		wantSegment  int
+		wantRetry    int
+		wantInterval time.Duration
+	}{
+		{0, 2, 6, 10 * time.Second},
+		{0.5, 6, 4, 5 * time.Second},
+		{0.75, 8, 3, 2500 * time.Millisecond},
+		{1, 10, 2, 0},
+	}
+
+	for _, tt := range tests {
+		warmUpProgress = tt.progress
+		if got := WarmUpSegmentSize(10); got != tt.wantSegment {
+			t.Errorf("progress %.2f: WarmUpSegmentSize(10) = %d, expected %d", tt.progress, got, tt.wantSegment)
+		}
+		if got := warmUpRetrySeconds(2); got != tt.wantRetry {
+			t.Errorf("progress %.2f: warmUpRetrySeconds(2) = %d, expected %d", tt.progress, got, tt.wantRetry)
+		}
+		if got := warmUpRequestInterval(); got != tt.wantInterval {
+			t.Errorf("progress %.2f: warmUpRequestInterval() = %v, expected %v", tt.progress, got, tt.wantInterval)
+		}
+	}
+}
+
+func TestCheckerObjectKey(t *testing.T) {
+	tests := []struct {
+		base, checker, expected string
+	}{
+		{"warm_up_state.json", "sale-checker", "warm_up_state.sale-checker.json"},
+		{"state/run_history.json", "sale-checker", "state/run_history.sale-checker.json"},
+		{"state/warm_up", "new-release-checker", "state/warm_up.new-release-checker"},
+	}
+	for _, tt := range tests {
+		if got := CheckerObjectKey(tt.base, tt.checker); got != tt.expected {
+			t.Errorf("CheckerObjectKey(%q, %q) = %q, expected %q", tt.base, tt.checker, got, tt.expected)
+		}
+	}
+}